@@ -0,0 +1,56 @@
+package nf9packet
+
+// ApplicationResolver resolves APPLICATION_TAG values of Flow Data Records,
+// as exported by Cisco NBAR, to application names announced by exporters in
+// Options Data Records.
+type ApplicationResolver struct {
+	// Tables applications are looked up in. If nil, no applications are
+	// known.
+	Tables *OptionsTables
+}
+
+// NewApplicationResolver creates a resolver using applications from tables.
+func NewApplicationResolver(tables *OptionsTables) *ApplicationResolver {
+	return &ApplicationResolver{Tables: tables}
+}
+
+// Resolve returns application with raw APPLICATION_TAG tag announced by
+// Observation Domain key. If the application is not known, only Tag of the
+// returned application is set.
+func (a *ApplicationResolver) Resolve(key DomainKey, tag []byte) (Application, bool) {
+	if a.Tables != nil {
+		if app, ok := a.Tables.Application(key, tag); ok {
+			return app, true
+		}
+	}
+	return Application{Tag: append([]byte(nil), tag...)}, false
+}
+
+// Enrich returns application of Flow Data Record r decoded with template tpl
+// and received from Observation Domain key, together with its parsed tag.
+// False is returned if the record has no valid APPLICATION_TAG or the
+// application is not known.
+func (a *ApplicationResolver) Enrich(key DomainKey, tpl *TemplateRecord, r *FlowDataRecord) (Application, ApplicationTag, bool) {
+	data := findValue(tpl.Fields, r.Values, fieldApplicationTag)
+	tag, err := ParseApplicationTag(data)
+	if err != nil {
+		return Application{}, tag, false
+	}
+	app, ok := a.Resolve(key, data)
+	return app, tag, ok
+}
+
+// Name returns application name of Flow Data Record r, see Enrich. If the
+// application is not known its tag is returned in "engine:selector" format,
+// empty string is returned if the record has no valid APPLICATION_TAG.
+func (a *ApplicationResolver) Name(key DomainKey, tpl *TemplateRecord, r *FlowDataRecord) string {
+	app, tag, ok := a.Enrich(key, tpl, r)
+	switch {
+	case ok && app.Name != "":
+		return app.Name
+	case tag.Engine != 0:
+		return tag.String()
+	default:
+		return ""
+	}
+}
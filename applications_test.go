@@ -0,0 +1,71 @@
+package nf9packet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplicationTag(t *testing.T) {
+	tag, err := ParseApplicationTag([]byte{3, 0, 0, 0x01, 0xbb})
+	require.NoError(t, err)
+	assert.Equal(t, ApplicationTag{Engine: EngineIANAL4, Selector: 443}, tag)
+	assert.Equal(t, "IANA-L4:443", tag.String())
+	assert.Equal(t, "42:7", ApplicationTag{Engine: 42, Selector: 7}.String())
+
+	f := Field{Type: 95, Length: 4}
+	assert.Equal(t, "PANA-L7:80", f.DataToString([]byte{13, 0, 0, 0x50}))
+	assert.Equal(t, "0x0d", f.DataToString([]byte{13}))
+
+	// PANA-L7-PEN selector is preceded by Private Enterprise Number.
+	tag, err = ParseApplicationTag([]byte{20, 0, 0, 0, 9, 0, 0, 0, 0, 0, 0, 0x01, 0x02})
+	require.NoError(t, err)
+	assert.Equal(t, ApplicationTag{Engine: EnginePANAL7PEN, PEN: 9, Selector: 0x0102}, tag)
+	assert.Equal(t, "PANA-L7-PEN:9:258", tag.String())
+
+	_, err = ParseApplicationTag([]byte{20, 0, 0, 0, 9})
+	assert.ErrorIs(t, err, ErrValueLength)
+	_, err = ParseApplicationTag(make([]byte, 10))
+	assert.ErrorIs(t, err, ErrValueLength)
+}
+
+func TestApplicationResolver(t *testing.T) {
+	tables := NewOptionsTables()
+	tables.LearnOptions("exporter", &OptionsRecords{
+		&Packet{Version: 9, SourceId: 1},
+		&OptionsTemplateRecord{
+			TemplateId: 256,
+			Scopes:     []Field{{Type: 1, Length: 4}},
+			Options:    []Field{{Type: 95, Length: 4}, {Type: 96, Length: 8}},
+		},
+		[]OptionsDataRecord{{[][]byte{{0, 0, 0, 1}}, [][]byte{{13, 0, 0x01, 0xc2}, []byte("webex\x00\x00\x00")}}},
+	})
+	r := NewApplicationResolver(tables)
+	key := DomainKey{"exporter", 1}
+
+	tpl := &TemplateRecord{256, 2, []Field{{Type: 8, Length: 4}, {Type: 95, Length: 4}}}
+	known := &FlowDataRecord{[][]byte{{10, 0, 0, 1}, {13, 0, 0x01, 0xc2}}}
+	unknown := &FlowDataRecord{[][]byte{{10, 0, 0, 1}, {3, 0, 0, 0x35}}}
+
+	app, tag, ok := r.Enrich(key, tpl, known)
+	require.True(t, ok)
+	assert.Equal(t, "webex", app.Name)
+	assert.Equal(t, ApplicationTag{Engine: EnginePANAL7, Selector: 450}, tag)
+	assert.Equal(t, "webex", r.Name(key, tpl, known))
+
+	_, _, ok = r.Enrich(key, tpl, unknown)
+	assert.False(t, ok)
+	assert.Equal(t, "IANA-L4:53", r.Name(key, tpl, unknown))
+
+	// Applications are not shared between Observation Domains.
+	assert.Equal(t, "PANA-L7:450", r.Name(DomainKey{"exporter", 2}, tpl, known))
+
+	assert.Equal(t, "", r.Name(key, &testDecodeTemplate, known))
+
+	// Resolver without tables knows no applications.
+	var empty ApplicationResolver
+	_, _, ok = empty.Enrich(key, tpl, known)
+	assert.False(t, ok)
+	assert.Equal(t, "PANA-L7:450", empty.Name(key, tpl, known))
+}
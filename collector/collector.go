@@ -0,0 +1,268 @@
+// Package collector implements a UDP collector for NetFlow v5, v7, v9 and
+// IPFIX packets built on top of nf9packet.Session.
+//
+// Collector listens on one or more UDP addresses, reads packets using several
+// goroutines, decodes them using a shared template cache and passes decoded
+// packets to a Handler:
+//
+//	c := collector.New(collector.HandlerFunc(func(addr net.Addr, p *nf9packet.SessionPacket) {
+//		...
+//	}), ":2055")
+//	err := c.ListenAndServe(ctx)
+//
+// Collector stops when the context is cancelled.
+package collector
+
+import (
+	"context"
+	"errors"
+	"net"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fln/nf9packet"
+)
+
+// Handler processes decoded packets. HandlePacket is called from multiple
+// goroutines concurrently, but packets with the same exporter address and
+// SourceId are handled one at a time in order of arrival, unless Collector
+// Workers is zero.
+type Handler interface {
+	HandlePacket(addr net.Addr, p *nf9packet.SessionPacket)
+}
+
+// HandlerFunc is an adapter to use an ordinary function as a Handler.
+type HandlerFunc func(addr net.Addr, p *nf9packet.SessionPacket)
+
+// HandlePacket calls f(addr, p).
+func (f HandlerFunc) HandlePacket(addr net.Addr, p *nf9packet.SessionPacket) {
+	f(addr, p)
+}
+
+// Message is a decoded packet delivered by ChanHandler.
+type Message struct {
+	// Address of the exporter that sent the packet.
+	Addr net.Addr
+
+	// Decoded packet.
+	Packet *nf9packet.SessionPacket
+}
+
+// ChanHandler returns a Handler sending decoded packets to ch. Readers block
+// while ch is full.
+func ChanHandler(ch chan<- Message) Handler {
+	return HandlerFunc(func(addr net.Addr, p *nf9packet.SessionPacket) {
+		ch <- Message{addr, p}
+	})
+}
+
+// Collector receives packets on UDP sockets and decodes them.
+type Collector struct {
+	// UDP addresses to listen on.
+	Addrs []string
+
+	// Number of goroutines reading packets from every address. Every
+	// reader has its own socket bound with SO_REUSEPORT, so packets of an
+	// exporter are always read by the same reader. Where SO_REUSEPORT is
+	// not available a single reader is used, as concurrent readers of a
+	// shared socket could pass packets of an exporter to workers out of
+	// order.
+	Readers int
+
+	// Size of the read buffer, longer packets are truncated.
+	BufferSize int
+
+	// Number of goroutines decoding packets. Packets are assigned to
+	// workers by exporter address and SourceId, so packets of a single
+	// Observation Domain are decoded in order while different domains are
+	// decoded in parallel. If zero, readers decode packets themselves and
+	// the order is not preserved.
+	Workers int
+
+	// Number of packets waiting in the queue of every worker. Packets
+	// arriving to a full queue are dropped and counted in Stats.
+	QueueLength int
+
+	// Session used to decode packets. It is shared by all workers.
+	Session *nf9packet.Session
+
+	// Handler receiving decoded packets.
+	Handler Handler
+
+	// Optional function called for packets that could not be decoded and
+	// for read errors. After a read error the reader pauses before the next
+	// read, for up to a second if errors persist.
+	OnError func(addr net.Addr, err error)
+
+	conns    []net.PacketConn
+	workers  []*worker
+	received uint64
+}
+
+// New creates a collector listening on addrs with a new Session, a reader per
+// CPU for every address and a worker per CPU.
+func New(handler Handler, addrs ...string) *Collector {
+	return &Collector{
+		Addrs:       addrs,
+		Readers:     runtime.NumCPU(),
+		BufferSize:  65535,
+		Workers:     runtime.NumCPU(),
+		QueueLength: 1024,
+		Session:     nf9packet.NewSession(),
+		Handler:     handler,
+	}
+}
+
+// Listen opens UDP sockets for all addresses and prepares worker queues.
+func (c *Collector) Listen() error {
+	readers := c.Readers
+	if readers < 1 {
+		readers = 1
+	}
+	for _, addr := range c.Addrs {
+		conns, err := listen(addr, readers)
+		if err != nil {
+			c.close()
+			return err
+		}
+		c.conns = append(c.conns, conns...)
+	}
+	c.newWorkers()
+	return nil
+}
+
+// listen opens a socket on addr, or with SO_REUSEPORT a socket per reader.
+func listen(addr string, readers int) ([]net.PacketConn, error) {
+	if !reusePort || readers == 1 {
+		conn, err := net.ListenPacket("udp", addr)
+		if err != nil {
+			return nil, err
+		}
+		return []net.PacketConn{conn}, nil
+	}
+
+	var conns []net.PacketConn
+	for i := 0; i < readers; i++ {
+		conn, err := reusePortConfig.ListenPacket(context.Background(), "udp", addr)
+		if err != nil {
+			for _, c := range conns {
+				c.Close()
+			}
+			return nil, err
+		}
+		// Other sockets must bind to the same port, even if the port
+		// was chosen by the system.
+		addr = conn.LocalAddr().String()
+		conns = append(conns, conn)
+	}
+	return conns, nil
+}
+
+// LocalAddrs returns addresses of open sockets, one per address in Addrs.
+func (c *Collector) LocalAddrs() []net.Addr {
+	var addrs []net.Addr
+	for _, conn := range c.conns {
+		if len(addrs) == 0 || addrs[len(addrs)-1].String() != conn.LocalAddr().String() {
+			addrs = append(addrs, conn.LocalAddr())
+		}
+	}
+	return addrs
+}
+
+func (c *Collector) close() {
+	for _, conn := range c.conns {
+		conn.Close()
+	}
+	c.conns = nil
+}
+
+// Serve reads and decodes packets from sockets opened by Listen until ctx is
+// cancelled. Sockets are closed and nil is returned once all readers stop and
+// workers handle packets left in their queues.
+func (c *Collector) Serve(ctx context.Context) error {
+	if len(c.conns) == 0 {
+		return errors.New("Collector is not listening.")
+	}
+
+	if len(c.workers) > 0 {
+		workers := c.startWorkers()
+		defer c.stopWorkers(workers)
+	}
+
+	var wg sync.WaitGroup
+	for _, conn := range c.conns {
+		wg.Add(1)
+		go func(conn net.PacketConn) {
+			defer wg.Done()
+			c.read(conn)
+		}(conn)
+	}
+
+	<-ctx.Done()
+	c.close()
+	wg.Wait()
+	return nil
+}
+
+// ListenAndServe calls Listen and Serve.
+func (c *Collector) ListenAndServe(ctx context.Context) error {
+	if err := c.Listen(); err != nil {
+		return err
+	}
+	return c.Serve(ctx)
+}
+
+// Read errors other than closing of the socket are retried after a delay
+// growing from minReadBackoff to maxReadBackoff, so a persistent error does
+// not keep a CPU busy.
+const (
+	minReadBackoff = 5 * time.Millisecond
+	maxReadBackoff = time.Second
+)
+
+func (c *Collector) read(conn net.PacketConn) {
+	buf := make([]byte, c.BufferSize)
+	var backoff time.Duration
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			c.error(addr, err)
+			if backoff *= 2; backoff < minReadBackoff {
+				backoff = minReadBackoff
+			} else if backoff > maxReadBackoff {
+				backoff = maxReadBackoff
+			}
+			time.Sleep(backoff)
+			continue
+		}
+		backoff = 0
+		atomic.AddUint64(&c.received, 1)
+
+		if len(c.workers) > 0 {
+			c.dispatch(addr, append([]byte(nil), buf[:n]...))
+		} else {
+			// Session.Decode does not keep references to buf.
+			c.decode(addr, buf[:n])
+		}
+	}
+}
+
+func (c *Collector) decode(addr net.Addr, data []byte) {
+	p, err := c.Session.Decode(addr.String(), data)
+	if err != nil {
+		c.error(addr, err)
+		return
+	}
+	c.Handler.HandlePacket(addr, p)
+}
+
+func (c *Collector) error(addr net.Addr, err error) {
+	if c.OnError != nil {
+		c.OnError(addr, err)
+	}
+}
@@ -0,0 +1,259 @@
+package collector
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fln/nf9packet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testTemplate = nf9packet.TemplateRecord{
+	TemplateId: 256,
+	Fields: []nf9packet.Field{
+		{Type: 8, Length: 4}, // IPV4_SRC_ADDR
+		{Type: 7, Length: 2}, // L4_SRC_PORT
+	},
+}
+
+func testPacket(t *testing.T, sourceId uint32, sequence uint32) []byte {
+	set, err := testTemplate.EncodeFlowSet([]nf9packet.FlowDataRecord{
+		{Values: [][]byte{{10, 0, 0, 1}, {0, byte(sequence)}}},
+	})
+	require.NoError(t, err)
+
+	data, err := nf9packet.Encode(&nf9packet.Packet{
+		Version:        9,
+		SequenceNumber: sequence,
+		SourceId:       sourceId,
+		FlowSets:       []nf9packet.FlowSet{nf9packet.NewTemplateFlowSet(testTemplate), set},
+	})
+	require.NoError(t, err)
+	return data
+}
+
+func startCollector(t *testing.T, c *Collector) (net.Conn, func()) {
+	c.Addrs = []string{"127.0.0.1:0"}
+	require.NoError(t, c.Listen())
+	require.Len(t, c.LocalAddrs(), 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- c.Serve(ctx)
+	}()
+
+	conn, err := net.Dial("udp", c.LocalAddrs()[0].String())
+	require.NoError(t, err)
+
+	return conn, func() {
+		conn.Close()
+		cancel()
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Collector did not stop")
+		}
+	}
+}
+
+func TestCollector(t *testing.T) {
+	ch := make(chan Message, 1)
+	errs := make(chan error, 1)
+	c := New(ChanHandler(ch))
+	c.Readers = 4
+	c.OnError = func(addr net.Addr, err error) { errs <- err }
+
+	conn, stop := startCollector(t, c)
+	defer stop()
+
+	_, err := conn.Write(testPacket(t, 7, 1))
+	require.NoError(t, err)
+
+	select {
+	case msg := <-ch:
+		assert.Equal(t, conn.LocalAddr().String(), msg.Addr.String())
+		assert.Equal(t, uint32(7), msg.Packet.SourceId)
+		require.Len(t, msg.Packet.Flows, 1)
+		assert.Equal(t, []byte{10, 0, 0, 1}, msg.Packet.Flows[0].Records[0].Values[0])
+	case <-time.After(5 * time.Second):
+		t.Fatal("No packet received")
+	}
+
+	_, err = conn.Write([]byte{0, 9})
+	require.NoError(t, err)
+	select {
+	case err := <-errs:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("No error reported")
+	}
+}
+
+func TestCollectorNotListening(t *testing.T) {
+	assert.Error(t, New(nil).Serve(context.Background()))
+}
+
+func TestCollectorOrdering(t *testing.T) {
+	ch := make(chan Message, 100)
+	c := New(ChanHandler(ch))
+	c.Readers = 4
+	c.Workers = 4
+
+	conn, stop := startCollector(t, c)
+	defer stop()
+
+	// Sequence of every SourceId must be preserved.
+	for i := 0; i < 50; i++ {
+		_, err := conn.Write(testPacket(t, uint32(i%5), uint32(i)))
+		require.NoError(t, err)
+	}
+
+	last := make(map[uint32]int)
+	for i := 0; i < 50; i++ {
+		select {
+		case msg := <-ch:
+			sequence := int(msg.Packet.SequenceNumber)
+			if prev, ok := last[msg.Packet.SourceId]; ok {
+				assert.Greater(t, sequence, prev)
+			}
+			last[msg.Packet.SourceId] = sequence
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Received %d of 50 packets", i)
+		}
+	}
+	assert.Equal(t, uint64(50), c.Stats().Received)
+}
+
+func TestCollectorOrderingSharedSocket(t *testing.T) {
+	defer func(reuse bool) { reusePort = reuse }(reusePort)
+	reusePort = false
+
+	ch := make(chan Message, 200)
+	c := New(ChanHandler(ch))
+	c.Readers = 4
+	c.Workers = 4
+
+	conn, stop := startCollector(t, c)
+	defer stop()
+
+	// Readers of a shared socket would race to dispatch packets.
+	assert.Len(t, c.conns, 1)
+
+	for i := 0; i < 200; i++ {
+		_, err := conn.Write(testPacket(t, uint32(i%3), uint32(i)))
+		require.NoError(t, err)
+	}
+
+	last := make(map[uint32]int)
+	for i := 0; i < 200; i++ {
+		select {
+		case msg := <-ch:
+			sequence := int(msg.Packet.SequenceNumber)
+			if prev, ok := last[msg.Packet.SourceId]; ok {
+				assert.Greater(t, sequence, prev)
+			}
+			last[msg.Packet.SourceId] = sequence
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Received %d of 200 packets", i)
+		}
+	}
+}
+
+func TestCollectorDispatch(t *testing.T) {
+	block := make(chan struct{})
+	c := New(HandlerFunc(func(addr net.Addr, p *nf9packet.SessionPacket) {
+		<-block
+	}))
+	c.Workers = 2
+	c.QueueLength = 1
+	c.newWorkers()
+
+	addr := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 2055}
+	data := testPacket(t, 7, 0)
+	for i := 0; i < 5; i++ {
+		c.dispatch(addr, data)
+	}
+
+	// Worker queue holds a single packet, nothing is consuming it.
+	stats := c.Stats()
+	assert.Equal(t, uint64(4), stats.Dropped)
+	assert.Equal(t, uint64(4), stats.WorkerDropped[shard(addr.String(), 7, 2)])
+
+	wg := c.startWorkers()
+	close(block)
+	c.stopWorkers(wg)
+}
+
+// errorConn fails every read until it is closed.
+type errorConn struct {
+	net.PacketConn
+	closed chan struct{}
+}
+
+func (c *errorConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	select {
+	case <-c.closed:
+		return 0, nil, net.ErrClosed
+	default:
+		return 0, nil, errors.New("read failed")
+	}
+}
+
+func TestCollectorReadBackoff(t *testing.T) {
+	var errs int64
+	c := New(nil)
+	c.OnError = func(addr net.Addr, err error) { atomic.AddInt64(&errs, 1) }
+
+	conn := &errorConn{closed: make(chan struct{})}
+	done := make(chan struct{})
+	go func() {
+		c.read(conn)
+		close(done)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	close(conn.closed)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Reader did not stop")
+	}
+
+	// 5, 10, 20 and 40 ms delays fit into 100 ms.
+	n := atomic.LoadInt64(&errs)
+	assert.GreaterOrEqual(t, n, int64(2))
+	assert.LessOrEqual(t, n, int64(6))
+}
+
+func TestShard(t *testing.T) {
+	v9 := make([]byte, 20)
+	v9[1] = 9
+	binary.BigEndian.PutUint32(v9[16:], 0x01020304)
+	assert.Equal(t, uint32(0x01020304), sourceId(v9))
+
+	ipfix := make([]byte, 16)
+	ipfix[1] = 10
+	binary.BigEndian.PutUint32(ipfix[12:], 7)
+	assert.Equal(t, uint32(7), sourceId(ipfix))
+
+	v5 := make([]byte, 24)
+	v5[1], v5[20], v5[21] = 5, 1, 2
+	assert.Equal(t, uint32(0x0102), sourceId(v5))
+	assert.Equal(t, uint32(0), sourceId(v5[:10]))
+
+	used := make(map[int]bool)
+	for i := uint32(0); i < 100; i++ {
+		n := shard("192.0.2.1:2055", i, 4)
+		assert.Equal(t, n, shard("192.0.2.1:2055", i, 4))
+		used[n] = true
+	}
+	assert.Len(t, used, 4)
+}
@@ -0,0 +1,7 @@
+//go:build linux && (386 || amd64 || arm)
+
+package collector
+
+// Package syscall of these platforms was frozen before SO_REUSEPORT was
+// added, the value is the same for all of them.
+const soReusePort = 0xf
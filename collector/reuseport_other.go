@@ -0,0 +1,12 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package collector
+
+import (
+	"net"
+)
+
+// SO_REUSEPORT is not available, a single reader is used.
+var reusePort = false
+
+var reusePortConfig net.ListenConfig
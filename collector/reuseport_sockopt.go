@@ -0,0 +1,9 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd || (linux && !(386 || amd64 || arm))
+
+package collector
+
+import (
+	"syscall"
+)
+
+const soReusePort = syscall.SO_REUSEPORT
@@ -0,0 +1,22 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package collector
+
+import (
+	"net"
+	"syscall"
+)
+
+var reusePort = true
+
+var reusePortConfig = net.ListenConfig{
+	Control: func(network, address string, c syscall.RawConn) error {
+		var err error
+		if cerr := c.Control(func(fd uintptr) {
+			err = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, soReusePort, 1)
+		}); cerr != nil {
+			return cerr
+		}
+		return err
+	},
+}
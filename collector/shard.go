@@ -0,0 +1,117 @@
+package collector
+
+import (
+	"encoding/binary"
+	"net"
+	"sync"
+	"sync/atomic"
+)
+
+// Stats holds collector counters.
+type Stats struct {
+	// Packets received from all sockets.
+	Received uint64
+
+	// Packets dropped because the worker queue was full.
+	Dropped uint64
+
+	// Packets dropped by every worker.
+	WorkerDropped []uint64
+}
+
+type datagram struct {
+	addr net.Addr
+	data []byte
+}
+
+type worker struct {
+	queue   chan datagram
+	dropped uint64
+}
+
+// sourceId returns Observation Domain of a raw packet without decoding it.
+// NetFlow v5 engine type and ID are used the same way DecodeV5 does.
+func sourceId(data []byte) uint32 {
+	if len(data) < 2 {
+		return 0
+	}
+	switch binary.BigEndian.Uint16(data) {
+	case 5:
+		if len(data) >= 22 {
+			return uint32(data[20])<<8 | uint32(data[21])
+		}
+	case 9:
+		if len(data) >= 20 {
+			return binary.BigEndian.Uint32(data[16:])
+		}
+	case 10:
+		if len(data) >= 16 {
+			return binary.BigEndian.Uint32(data[12:])
+		}
+	}
+	return 0
+}
+
+// shard returns worker index for packets of exporter addr with the given
+// SourceId. It is a 32-bit FNV-1a hash of both.
+func shard(addr string, sourceId uint32, workers int) int {
+	h := uint32(2166136261)
+	for i := 0; i < len(addr); i++ {
+		h = (h ^ uint32(addr[i])) * 16777619
+	}
+	for i := 0; i < 4; i++ {
+		h = (h ^ (sourceId >> (8 * i) & 0xff)) * 16777619
+	}
+	return int(h % uint32(workers))
+}
+
+func (c *Collector) newWorkers() {
+	c.workers = make([]*worker, c.Workers)
+	for i := range c.workers {
+		c.workers[i] = &worker{queue: make(chan datagram, c.QueueLength)}
+	}
+}
+
+func (c *Collector) startWorkers() *sync.WaitGroup {
+	var wg sync.WaitGroup
+	for _, w := range c.workers {
+		wg.Add(1)
+		go func(w *worker) {
+			defer wg.Done()
+			for d := range w.queue {
+				c.decode(d.addr, d.data)
+			}
+		}(w)
+	}
+	return &wg
+}
+
+func (c *Collector) stopWorkers(wg *sync.WaitGroup) {
+	for _, w := range c.workers {
+		close(w.queue)
+	}
+	wg.Wait()
+}
+
+// dispatch queues a packet to the worker owning its exporter and SourceId.
+// Data must not be reused by the caller. Packet is dropped if the queue is
+// full.
+func (c *Collector) dispatch(addr net.Addr, data []byte) {
+	w := c.workers[shard(addr.String(), sourceId(data), len(c.workers))]
+	select {
+	case w.queue <- datagram{addr, data}:
+	default:
+		atomic.AddUint64(&w.dropped, 1)
+	}
+}
+
+// Stats returns current collector counters.
+func (c *Collector) Stats() Stats {
+	s := Stats{Received: atomic.LoadUint64(&c.received)}
+	for _, w := range c.workers {
+		dropped := atomic.LoadUint64(&w.dropped)
+		s.Dropped += dropped
+		s.WorkerDropped = append(s.WorkerDropped, dropped)
+	}
+	return s
+}
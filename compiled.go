@@ -0,0 +1,131 @@
+package nf9packet
+
+// CompiledTemplate is a TemplateRecord prepared for fast decoding. All fields
+// of a NetFlow v9 template have fixed length, so offsets of every value in a
+// record are known in advance and decoding a Data FlowSet is reduced to index
+// arithmetic. CompiledTemplate is immutable and safe for concurrent use.
+type CompiledTemplate struct {
+	// Template ID of the compiled template.
+	TemplateId uint16
+
+	// Template fields.
+	Fields []Field
+
+	// Offset of every field value from the start of a record.
+	Offsets []int
+
+	// Length of a single record in bytes.
+	RecordLength int
+
+	decoders []func([]byte) (Value, error)
+}
+
+// Compile prepares current TemplateRecord for fast decoding. Templates with
+// variable length fields (IPFIX) and templates describing zero length records
+// can not be compiled, *DecodeError wrapping ErrBadTemplate is returned for
+// them.
+func (dtpl *TemplateRecord) Compile() (*CompiledTemplate, error) {
+	c := &CompiledTemplate{
+		TemplateId: dtpl.TemplateId,
+		Fields:     copyFields(dtpl.Fields),
+		Offsets:    make([]int, len(dtpl.Fields)),
+		decoders:   make([]func([]byte) (Value, error), len(dtpl.Fields)),
+	}
+
+	for i := range c.Fields {
+		f := &c.Fields[i]
+		if f.Length == VariableLength {
+			return nil, errorNotCompilable(dtpl.TemplateId, "field "+f.Name()+" has variable length")
+		}
+		c.Offsets[i] = c.RecordLength
+		c.RecordLength += int(f.Length)
+
+		c.decoders[i] = fieldToValueBytes
+		if e, ok := f.dbEntry(); ok {
+			c.decoders[i] = e.Value
+		}
+	}
+	if c.RecordLength == 0 {
+		return nil, errorNotCompilable(dtpl.TemplateId, "records have zero length")
+	}
+
+	return c, nil
+}
+
+// RecordCount returns number of records in Data FlowSet set. Data length must
+// be a multiple of the record length plus up to 3 bytes of padding, otherwise
+// a *DecodeError wrapping ErrTruncatedRecord is returned together with the
+// number of complete records. *DecodeError wrapping ErrTemplateMismatch is
+// returned if the Data FlowSet does not belong to this template.
+func (c *CompiledTemplate) RecordCount(set *DataFlowSet) (int, error) {
+	if set.Id != c.TemplateId {
+		return 0, errorTemplateMismatch(set.Id, c.TemplateId)
+	}
+
+	// Records are read while at least 4 bytes are left, the same way as
+	// TemplateRecord.DecodeRecords does.
+	if len(set.Data) < 4 {
+		return 0, nil
+	}
+	n := (len(set.Data)-4)/c.RecordLength + 1
+	if n*c.RecordLength > len(set.Data) {
+		n--
+		offset := n * c.RecordLength
+		return n, errorTruncatedRecord(set.Id, offset, len(set.Data)-offset)
+	}
+	return n, nil
+}
+
+// Record returns raw bytes of record i in Data FlowSet set. The set must be
+// validated with RecordCount first.
+func (c *CompiledTemplate) Record(set *DataFlowSet, i int) []byte {
+	return set.Data[i*c.RecordLength : (i+1)*c.RecordLength]
+}
+
+// Bytes returns raw value of field i in record. The returned slice points
+// into record.
+func (c *CompiledTemplate) Bytes(record []byte, i int) []byte {
+	return record[c.Offsets[i] : c.Offsets[i]+int(c.Fields[i].Length)]
+}
+
+// Value returns typed value of field i in record, see Field.Value.
+func (c *CompiledTemplate) Value(record []byte, i int) (Value, error) {
+	return c.decoders[i](c.Bytes(record, i))
+}
+
+// ForEachRecord calls fn with raw bytes of every record in Data FlowSet set.
+// Nothing is called if the Data FlowSet is invalid. Iteration stops early if
+// fn returns false.
+func (c *CompiledTemplate) ForEachRecord(set *DataFlowSet, fn func(record []byte) bool) error {
+	n, err := c.RecordCount(set)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		if !fn(c.Record(set, i)) {
+			break
+		}
+	}
+	return nil
+}
+
+// DecodeRecords decodes Data FlowSet set to a list of Flow Data Records. It
+// returns the same result as TemplateRecord.DecodeRecords, but values of all
+// records share a single allocation.
+func (c *CompiledTemplate) DecodeRecords(set *DataFlowSet) ([]FlowDataRecord, error) {
+	n, err := c.RecordCount(set)
+	if n == 0 {
+		return nil, err
+	}
+
+	list := make([]FlowDataRecord, n)
+	values := make([][]byte, n*len(c.Fields))
+	for i := range list {
+		record := c.Record(set, i)
+		list[i].Values = values[i*len(c.Fields) : (i+1)*len(c.Fields) : (i+1)*len(c.Fields)]
+		for j := range c.Fields {
+			list[i].Values[j] = c.Bytes(record, j)
+		}
+	}
+	return list, err
+}
@@ -0,0 +1,93 @@
+package nf9packet
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompiledTemplate(t *testing.T) {
+	c, err := testDecodeTemplate.Compile()
+	require.NoError(t, err)
+	assert.Equal(t, 6, c.RecordLength)
+	assert.Equal(t, []int{0, 4}, c.Offsets)
+
+	set := benchmarkFlowSet(3)
+	n, err := c.RecordCount(set)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	record := c.Record(set, 2)
+	assert.Equal(t, []byte{0, 2}, c.Bytes(record, 1))
+	value, err := c.Value(record, 0)
+	require.NoError(t, err)
+	assert.Equal(t, netip.MustParseAddr("10.0.0.2"), value)
+
+	expected, err := testDecodeTemplate.DecodeRecords(set)
+	require.NoError(t, err)
+	actual, err := c.DecodeRecords(set)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+func TestCompiledTemplateErrors(t *testing.T) {
+	_, err := (&TemplateRecord{TemplateId: 256, Fields: []Field{{Type: 8, Length: VariableLength}}}).Compile()
+	assert.True(t, errors.Is(err, ErrBadTemplate))
+	var decodeErr *DecodeError
+	require.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, uint16(256), decodeErr.TemplateId)
+	assert.Equal(t, "Invalid template 256, field IPV4_SRC_ADDR has variable length.", err.Error())
+	_, err = (&TemplateRecord{TemplateId: 256}).Compile()
+	assert.True(t, errors.Is(err, ErrBadTemplate))
+
+	c, err := testDecodeTemplate.Compile()
+	require.NoError(t, err)
+
+	tests := []struct {
+		length int
+		count  int
+		err    error
+	}{
+		{0, 0, nil},
+		{3, 0, nil},
+		{5, 0, ErrTruncatedRecord},
+		{6, 1, nil},
+		{9, 1, nil},
+		{10, 1, ErrTruncatedRecord},
+		{12, 2, nil},
+		{16, 2, ErrTruncatedRecord},
+	}
+	for _, test := range tests {
+		set := DataFlowSet{FlowSetHeader: FlowSetHeader{256, 0}, Data: make([]byte, test.length)}
+		n, err := c.RecordCount(&set)
+		assert.Equal(t, test.count, n, "length %d", test.length)
+		if test.err == nil {
+			assert.NoError(t, err, "length %d", test.length)
+		} else {
+			assert.True(t, errors.Is(err, test.err), "length %d", test.length)
+		}
+
+		records, _ := testDecodeTemplate.DecodeRecords(&set)
+		assert.Len(t, records, n, "length %d", test.length)
+	}
+
+	set := DataFlowSet{FlowSetHeader: FlowSetHeader{257, 0}}
+	_, err = c.RecordCount(&set)
+	assert.True(t, errors.Is(err, ErrTemplateMismatch))
+}
+
+func BenchmarkCompiledTemplate(b *testing.B) {
+	set := benchmarkFlowSet(100)
+	c, _ := testDecodeTemplate.Compile()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(set.Data)))
+	for i := 0; i < b.N; i++ {
+		c.ForEachRecord(set, func(record []byte) bool {
+			_ = c.Bytes(record, 0)
+			return true
+		})
+	}
+}
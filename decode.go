@@ -1,182 +1,226 @@
 package nf9packet
 
 import (
-	"bytes"
 	"encoding/binary"
-	"fmt"
-	"io"
 )
 
-func errorMissingData(bytes int) error {
-	return fmt.Errorf("Incomplete packet, missing at least %d bytes.", bytes)
-}
-
-func errorIncompatibleVersion(version uint16) error {
-	return fmt.Errorf("Incompatible protocol version v%d, only v9 is supported", version)
-}
-
-func errorExtraBytes(bytes int) error {
-	return fmt.Errorf("Extra %d bytes at the end of the packet.", bytes)
-}
-
-func parseFieldList(buf *bytes.Buffer, count int) (list []Field) {
-	list = make([]Field, count)
-
-	for i := 0; i < count; i++ {
-		binary.Read(buf, binary.BigEndian, &list[i])
+// parseFieldList reads count field definitions from data, which must be long
+// enough to hold them.
+func parseFieldList(data []byte, count int) []Field {
+	list := make([]Field, count)
+	for i := range list {
+		list[i].Type = binary.BigEndian.Uint16(data[i*fieldLength:])
+		list[i].Length = binary.BigEndian.Uint16(data[i*fieldLength+2:])
 	}
-
-	return
+	return list
 }
 
-func parseOptionsTemplateFlowSet(data []byte, header *FlowSetHeader) (interface{}, error) {
+func parseOptionsTemplateFlowSet(data []byte, header *FlowSetHeader, offset, index int) (FlowSet, error) {
 	var set OptionsTemplateFlowSet
-	var t OptionsTemplateRecord
 
-	set.Id = header.Id
-	set.Length = header.Length
+	set.FlowSetHeader = *header
 
-	buf := bytes.NewBuffer(data)
-	headerLen := binary.Size(t.TemplateId) + binary.Size(t.ScopeLength) + binary.Size(t.OptionLength)
-	for buf.Len() >= 4 { // Padding aligns to 4 byte boundary
-		if buf.Len() < headerLen {
-			return nil, errorMissingData(headerLen - buf.Len())
-		}
-		binary.Read(buf, binary.BigEndian, &t.TemplateId)
-		binary.Read(buf, binary.BigEndian, &t.ScopeLength)
-		binary.Read(buf, binary.BigEndian, &t.OptionLength)
+	const headerLen = 6
+	for pos := 0; len(data)-pos >= 4; { // Padding aligns to 4 byte boundary
+		var t OptionsTemplateRecord
 
-		if buf.Len() < int(t.ScopeLength)+int(t.OptionLength) {
-			return nil, errorMissingData(int(t.ScopeLength) + int(t.OptionLength) - buf.Len())
+		if len(data)-pos < headerLen {
+			return nil, errorMissingData(offset+pos, index, headerLen-(len(data)-pos))
 		}
+		t.TemplateId = binary.BigEndian.Uint16(data[pos:])
+		t.ScopeLength = binary.BigEndian.Uint16(data[pos+2:])
+		t.OptionLength = binary.BigEndian.Uint16(data[pos+4:])
 
-		scopeCount := int(t.ScopeLength) / binary.Size(Field{})
-		optionCount := int(t.OptionLength) / binary.Size(Field{})
+		if t.ScopeLength%fieldLength != 0 || t.OptionLength%fieldLength != 0 {
+			return nil, errorBadTemplate(offset+pos, index)
+		}
+		pos += headerLen
 
-		t.Scopes = parseFieldList(buf, scopeCount)
-		t.Options = parseFieldList(buf, optionCount)
+		fieldsLen := int(t.ScopeLength) + int(t.OptionLength)
+		if len(data)-pos < fieldsLen {
+			return nil, errorMissingData(offset+pos, index, fieldsLen-(len(data)-pos))
+		}
+		t.Scopes = parseFieldList(data[pos:], int(t.ScopeLength)/fieldLength)
+		pos += int(t.ScopeLength)
+		t.Options = parseFieldList(data[pos:], int(t.OptionLength)/fieldLength)
+		pos += int(t.OptionLength)
 
 		set.Records = append(set.Records, t)
 	}
 
-	return set, nil
+	return &set, nil
 }
 
-func parseTemplateFlowSet(data []byte, header *FlowSetHeader) (interface{}, error) {
+func parseTemplateFlowSet(data []byte, header *FlowSetHeader, offset, index int) (FlowSet, error) {
 	var set TemplateFlowSet
-	var t TemplateRecord
 
-	set.Id = header.Id
-	set.Length = header.Length
+	set.FlowSetHeader = *header
 
-	buf := bytes.NewBuffer(data)
-	headerLen := binary.Size(t.TemplateId) + binary.Size(t.FieldCount)
+	const headerLen = 4
+	for pos := 0; len(data)-pos >= 4; { // Padding aligns to 4 byte boundary
+		var t TemplateRecord
 
-	for buf.Len() >= 4 { // Padding aligns to 4 byte boundary
-		if buf.Len() < headerLen {
-			return nil, errorMissingData(headerLen - buf.Len())
-		}
-		binary.Read(buf, binary.BigEndian, &t.TemplateId)
-		binary.Read(buf, binary.BigEndian, &t.FieldCount)
+		t.TemplateId = binary.BigEndian.Uint16(data[pos:])
+		t.FieldCount = binary.BigEndian.Uint16(data[pos+2:])
+		pos += headerLen
 
-		fieldsLen := int(t.FieldCount) * binary.Size(Field{})
-		if fieldsLen > buf.Len() {
-			return nil, errorMissingData(fieldsLen - buf.Len())
+		fieldsLen := int(t.FieldCount) * fieldLength
+		if len(data)-pos < fieldsLen {
+			return nil, errorMissingData(offset+pos, index, fieldsLen-(len(data)-pos))
 		}
-		t.Fields = parseFieldList(buf, int(t.FieldCount))
+		t.Fields = parseFieldList(data[pos:], int(t.FieldCount))
+		pos += fieldsLen
 
 		set.Records = append(set.Records, t)
 	}
-	return set, nil
 
+	return &set, nil
 }
 
-func parseDataFlowSet(data []byte, header *FlowSetHeader) (interface{}, error) {
+func parseDataFlowSet(data []byte, header *FlowSetHeader) (FlowSet, error) {
 	var set DataFlowSet
 
-	set.Id = header.Id
-	set.Length = header.Length
+	set.FlowSetHeader = *header
 	set.Data = data
 
-	return set, nil
+	return &set, nil
 }
 
-func parseFlowSet(buf *bytes.Buffer) (interface{}, error) {
-	var setHeader FlowSetHeader
+// parseFlowSetHeader reads FlowSet (or IPFIX Set) header at offset in data
+// and returns it together with FlowSet body.
+func parseFlowSetHeader(data []byte, offset, index int) (FlowSetHeader, []byte, error) {
+	var header FlowSetHeader
 
-	if buf.Len() < binary.Size(setHeader) {
-		return nil, errorMissingData(binary.Size(setHeader) - buf.Len())
+	if len(data)-offset < flowSetHeaderLength {
+		return header, nil, errorMissingData(offset, index, flowSetHeaderLength-(len(data)-offset))
+	}
+	header.Id = binary.BigEndian.Uint16(data[offset:])
+	header.Length = binary.BigEndian.Uint16(data[offset+2:])
+
+	end := offset + int(header.Length)
+	if int(header.Length) < flowSetHeaderLength {
+		return header, nil, errorBadFlowSetLength(offset, index, int(header.Length))
+	}
+	if end > len(data) {
+		return header, nil, errorMissingData(offset+flowSetHeaderLength, index, end-len(data))
 	}
 
-	binary.Read(buf, binary.BigEndian, &setHeader)
+	return header, data[offset+flowSetHeaderLength : end : end], nil
+}
 
-	setDataLen := int(setHeader.Length) - binary.Size(setHeader)
-	if setDataLen > buf.Len() {
-		return nil, errorMissingData(setDataLen - buf.Len())
+// parseFlowSet parses FlowSet at offset in data. Index is the FlowSet number,
+// it is used for error reporting only. FlowSet length is returned even if its
+// contents are invalid, zero length means FlowSet boundaries are unknown.
+func parseFlowSet(data []byte, offset, index int) (FlowSet, int, error) {
+	header, body, err := parseFlowSetHeader(data, offset, index)
+	if err != nil {
+		return nil, 0, err
 	}
 
+	var set FlowSet
+	offset += flowSetHeaderLength
 	switch {
-	case setHeader.Id == 0:
-		return parseTemplateFlowSet(buf.Next(setDataLen), &setHeader)
-	case setHeader.Id == 1:
-		return parseOptionsTemplateFlowSet(buf.Next(setDataLen), &setHeader)
+	case header.Id == 0:
+		set, err = parseTemplateFlowSet(body, &header, offset, index)
+	case header.Id == 1:
+		set, err = parseOptionsTemplateFlowSet(body, &header, offset, index)
 	default:
-		return parseDataFlowSet(buf.Next(setDataLen), &setHeader)
+		set, err = parseDataFlowSet(body, &header)
 	}
+	return set, int(header.Length), err
+}
+
+// DecodeOptions control how Decode handles malformed packets.
+type DecodeOptions struct {
+	// Lenient mode keeps the FlowSets that were decoded successfully
+	// instead of failing the whole packet. Template FlowSets and Options
+	// Template FlowSets with invalid records are skipped, decoding stops at
+	// a truncated FlowSet or a FlowSet with invalid length and extra bytes at
+	// the end of the packet are ignored. Problems found are returned as a
+	// list of errors. Errors in the packet header are always fatal.
+	Lenient bool
+
+	// NoCopy makes Data FlowSets refer to the input buffer instead of a
+	// private copy, see DecodeNoCopy.
+	NoCopy bool
 }
 
 // Decode is the main function of this package. It converts raw packet bytes to
-// Packet struct.
+// Packet struct. Returned errors are of type *DecodeError.
 func Decode(data []byte) (*Packet, error) {
+	p, _, err := DecodeWithOptions(data, DecodeOptions{})
+	return p, err
+}
+
+// DecodeNoCopy is the same as Decode, but Data FlowSets of the returned packet
+// refer to data instead of a private copy. Caller passes the ownership of
+// data to the packet and must not modify or reuse data for as long as the
+// packet or any records decoded from it are in use.
+func DecodeNoCopy(data []byte) (*Packet, error) {
+	p, _, err := DecodeWithOptions(data, DecodeOptions{NoCopy: true})
+	return p, err
+}
+
+// DecodeWithOptions converts raw packet bytes to Packet struct the same way as
+// Decode does. In lenient mode problems that did not prevent decoding of the
+// packet are returned as a list of *DecodeError values, in strict mode the
+// list is always empty and the first problem is returned as the error.
+func DecodeWithOptions(data []byte, opts DecodeOptions) (*Packet, []error, error) {
 	var p Packet
-	var err error
+	var problems []error
 
 	// Create local copy of the "data" in case "data" slice is reused
 	// by the caller.
-	localData := make([]byte, len(data))
-	copy(localData, data)
-
-	buf := bytes.NewBuffer(localData)
-
-	if err := chainReads(buf, binary.BigEndian,
-		&p.Version,
-		&p.Count,
-		&p.SysUpTime,
-		&p.UnixSecs,
-		&p.SequenceNumber,
-		&p.SourceId,
-	); err != nil {
-		return nil, err
+	localData := data
+	if !opts.NoCopy {
+		localData = make([]byte, len(data))
+		copy(localData, data)
 	}
 
+	if len(localData) < packetHeaderLength {
+		return nil, nil, errorMissingData(len(localData), -1, packetHeaderLength-len(localData))
+	}
+	p.Version = binary.BigEndian.Uint16(localData[0:])
+	p.Count = binary.BigEndian.Uint16(localData[2:])
+	p.SysUpTime = binary.BigEndian.Uint32(localData[4:])
+	p.UnixSecs = binary.BigEndian.Uint32(localData[8:])
+	p.SequenceNumber = binary.BigEndian.Uint32(localData[12:])
+	p.SourceId = binary.BigEndian.Uint32(localData[16:])
+
 	if p.Version != 9 {
-		return nil, errorIncompatibleVersion(p.Version)
+		return nil, nil, errorIncompatibleVersion(p.Version)
 	}
 
-	p.FlowSets = make([]interface{}, 0, p.Count)
+	p.FlowSets = make([]FlowSet, 0, p.Count)
 
-	for i := 0; buf.Len() > 0 && i < int(p.Count); i++ {
-		p.FlowSets = p.FlowSets[0 : i+1]
-		p.FlowSets[i], err = parseFlowSet(buf)
+	pos := packetHeaderLength
+	for i := 0; pos < len(localData) && i < int(p.Count); i++ {
+		set, length, err := parseFlowSet(localData, pos, i)
 		if err != nil {
-			return nil, err
+			if !opts.Lenient {
+				return nil, nil, err
+			}
+			problems = append(problems, err)
+			if length == 0 {
+				// FlowSet boundaries are unknown, nothing else
+				// can be decoded.
+				pos = len(localData)
+				break
+			}
+			pos += length
+			continue
 		}
+		p.FlowSets = append(p.FlowSets, set)
+		pos += length
 	}
 
-	if buf.Len() > 0 {
-		return nil, errorExtraBytes(buf.Len())
-	}
-
-	return &p, nil
-}
-
-func chainReads(r io.Reader, order binary.ByteOrder, args ...interface{}) error {
-	var err error
-	for _, arg := range args {
-		err = binary.Read(r, order, arg)
-		if err != nil {
-			return err
+	if pos < len(localData) {
+		err := errorExtraBytes(pos, len(localData)-pos)
+		if !opts.Lenient {
+			return nil, nil, err
 		}
+		problems = append(problems, err)
 	}
-	return nil
+
+	return &p, problems, nil
 }
@@ -1,6 +1,7 @@
 package nf9packet
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -23,7 +24,7 @@ func TestDecodeValidHeader(t *testing.T) {
 		UnixSecs:       512,
 		SequenceNumber: 1024,
 		SourceId:       2048,
-		FlowSets:       []interface{}{},
+		FlowSets:       []FlowSet{},
 	}
 
 	actual, err := Decode(data)
@@ -56,3 +57,143 @@ func TestDecodeEmptyPacket(t *testing.T) {
 	assert.Nil(t, actual)
 
 }
+
+func TestDecodeErrors(t *testing.T) {
+	header := []byte{
+		0x00, 0x09, // Version
+		0x00, 0x01, // Records count
+		0x00, 0x00, 0x01, 0x00, // System uptime in milliseconds (256)
+		0x00, 0x00, 0x02, 0x00, // Timestamp (512)
+		0x00, 0x00, 0x04, 0x00, // Sequence number (1024)
+		0x00, 0x00, 0x08, 0x00, // Source ID (2048)
+	}
+	packet := func(body ...byte) []byte {
+		return append(append([]byte(nil), header...), body...)
+	}
+	version := packet()
+	version[1] = 5
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected DecodeError
+	}{
+		{"short header", header[:19], DecodeError{Err: ErrShortPacket, Offset: 19, FlowSet: -1, Bytes: 1}},
+		{"version", version, DecodeError{Err: ErrVersion, FlowSet: -1, Version: 5}},
+		{"short FlowSet header", packet(0x01, 0x00), DecodeError{Err: ErrShortPacket, Offset: 20, FlowSet: 0, Bytes: 2}},
+		{"FlowSet length", packet(0x01, 0x00, 0x00, 0x02), DecodeError{Err: ErrBadFlowSetLength, Offset: 20, FlowSet: 0, Bytes: 2}},
+		{"FlowSet data", packet(0x01, 0x00, 0x00, 0x0c, 0x00), DecodeError{Err: ErrShortPacket, Offset: 24, FlowSet: 0, Bytes: 7}},
+		{"template fields", packet(0x00, 0x00, 0x00, 0x08, 0x01, 0x00, 0x00, 0x01), DecodeError{Err: ErrShortPacket, Offset: 28, FlowSet: 0, Bytes: 4}},
+		{"options template", packet(0x00, 0x01, 0x00, 0x0c, 0x01, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x00), DecodeError{Err: ErrBadTemplate, Offset: 24, FlowSet: 0}},
+		{"trailing bytes", packet(0x01, 0x00, 0x00, 0x04, 0xff, 0xff), DecodeError{Err: ErrTrailingBytes, Offset: 24, FlowSet: -1, Bytes: 2}},
+	}
+
+	for _, test := range tests {
+		actual, err := Decode(test.data)
+		assert.Nil(t, actual, test.name)
+		require.Error(t, err, test.name)
+		assert.True(t, errors.Is(err, test.expected.Err), test.name)
+
+		var decodeErr *DecodeError
+		require.True(t, errors.As(err, &decodeErr), test.name)
+		assert.Equal(t, test.expected, *decodeErr, test.name)
+	}
+}
+
+func TestDecodeLenient(t *testing.T) {
+	data := []byte{
+		0x00, 0x09, // Version
+		0x00, 0x04, // Records count
+		0x00, 0x00, 0x01, 0x00, // System uptime in milliseconds (256)
+		0x00, 0x00, 0x02, 0x00, // Timestamp (512)
+		0x00, 0x00, 0x04, 0x00, // Sequence number (1024)
+		0x00, 0x00, 0x08, 0x00, // Source ID (2048)
+		// Template FlowSet
+		0x00, 0x00, 0x00, 0x0c, // FlowSet ID 0, length 12
+		0x01, 0x00, 0x00, 0x01, // Template ID 256, 1 field
+		0x00, 0x08, 0x00, 0x04, // IPV4_SRC_ADDR, 4 bytes
+		// Options Template FlowSet with invalid scope length
+		0x00, 0x01, 0x00, 0x0c, // FlowSet ID 1, length 12
+		0x01, 0x01, 0x00, 0x03, // Template ID 257, scope length 3
+		0x00, 0x00, 0x00, 0x00, // Option length 0, padding
+		// Data FlowSet
+		0x01, 0x00, 0x00, 0x08, // FlowSet ID 256, length 8
+		0x0a, 0x00, 0x00, 0x01, // 10.0.0.1
+		// Truncated Data FlowSet
+		0x01, 0x00, 0x00, 0x10, // FlowSet ID 256, length 16
+		0x0a, 0x00, 0x00, 0x02, // 10.0.0.2, rest is missing
+	}
+
+	actual, err := Decode(data)
+	assert.True(t, errors.Is(err, ErrBadTemplate))
+	assert.Nil(t, actual)
+
+	actual, problems, err := DecodeWithOptions(data, DecodeOptions{Lenient: true})
+	require.NoError(t, err)
+	require.Len(t, problems, 2)
+	assert.True(t, errors.Is(problems[0], ErrBadTemplate))
+	assert.True(t, errors.Is(problems[1], ErrShortPacket))
+
+	var decodeErr *DecodeError
+	require.True(t, errors.As(problems[1], &decodeErr))
+	assert.Equal(t, 3, decodeErr.FlowSet)
+
+	require.Len(t, actual.FlowSets, 2)
+	assert.Len(t, actual.TemplateRecords(), 1)
+	assert.Equal(t, []byte{0x0a, 0x00, 0x00, 0x01}, actual.DataFlowSets()[0].Data)
+}
+
+func TestDecodeLenientTrailingBytes(t *testing.T) {
+	data := []byte{
+		0x00, 0x09, // Version
+		0x00, 0x01, // Records count
+		0x00, 0x00, 0x01, 0x00, // System uptime in milliseconds (256)
+		0x00, 0x00, 0x02, 0x00, // Timestamp (512)
+		0x00, 0x00, 0x04, 0x00, // Sequence number (1024)
+		0x00, 0x00, 0x08, 0x00, // Source ID (2048)
+		0x01, 0x00, 0x00, 0x08, // FlowSet ID 256, length 8
+		0x0a, 0x00, 0x00, 0x01, // 10.0.0.1
+		0xff, 0xff, // Trailing garbage
+	}
+
+	actual, problems, err := DecodeWithOptions(data, DecodeOptions{})
+	assert.True(t, errors.Is(err, ErrTrailingBytes))
+	assert.Empty(t, problems)
+	assert.Nil(t, actual)
+
+	actual, problems, err = DecodeWithOptions(data, DecodeOptions{Lenient: true})
+	require.NoError(t, err)
+	require.Len(t, problems, 1)
+	assert.True(t, errors.Is(problems[0], ErrTrailingBytes))
+	assert.Len(t, actual.DataFlowSets(), 1)
+}
+
+func TestDecodeNoCopy(t *testing.T) {
+	data := append([]byte(nil), testDataPacket...)
+
+	copied, err := Decode(data)
+	require.NoError(t, err)
+	aliased, err := DecodeNoCopy(data)
+	require.NoError(t, err)
+	assert.Equal(t, copied, aliased)
+
+	data[len(data)-1] = 0xff
+	assert.Equal(t, byte(0xbb), copied.DataFlowSets()[0].Data[11])
+	assert.Equal(t, byte(0xff), aliased.DataFlowSets()[0].Data[11])
+}
+
+func BenchmarkDecode(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(testDataPacket)))
+	for i := 0; i < b.N; i++ {
+		Decode(testDataPacket)
+	}
+}
+
+func BenchmarkDecodeNoCopy(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(testDataPacket)))
+	for i := 0; i < b.N; i++ {
+		DecodeNoCopy(testDataPacket)
+	}
+}
@@ -20,11 +20,11 @@ func Dump(packet *Packet, w io.Writer) {
 
 	for i := range packet.FlowSets {
 		switch set := packet.FlowSets[i].(type) {
-		case DataFlowSet:
+		case *DataFlowSet:
 			set.dump(w)
-		case TemplateFlowSet:
+		case *TemplateFlowSet:
 			set.dump(w)
-		case OptionsTemplateFlowSet:
+		case *OptionsTemplateFlowSet:
 			set.dump(w)
 		}
 	}
@@ -60,7 +60,7 @@ func (dtpl *TemplateRecord) dump(w io.Writer) {
 	fmt.Fprintf(w, "\t\tTemplateId: %v\n", dtpl.TemplateId)
 	fmt.Fprintf(w, "\t\tFieldCount: %v\n", dtpl.FieldCount)
 	for i := range dtpl.Fields {
-		fmt.Fprintf(w, "\t\tType(%v), Len(%v)\n", dtpl.Fields[i].Type, dtpl.Fields[i].Length)
+		fmt.Fprintf(w, "\t\tType(%v), Len(%v)%s\n", dtpl.Fields[i].Type, dtpl.Fields[i].Length, dtpl.Fields[i].dumpEnterprise())
 	}
 }
 
@@ -70,9 +70,16 @@ func (otpl *OptionsTemplateRecord) dump(w io.Writer) {
 	fmt.Fprintf(w, "\t\tScopeLength: %v\n", otpl.ScopeLength)
 	fmt.Fprintf(w, "\t\tOptionLength: %v\n", otpl.OptionLength)
 	for i := range otpl.Scopes {
-		fmt.Fprintf(w, "\t\tScopeType(%v), Len(%v)\n", otpl.Scopes[i].Type, otpl.Scopes[i].Length)
+		fmt.Fprintf(w, "\t\tScopeType(%v), Len(%v)%s\n", otpl.Scopes[i].Type, otpl.Scopes[i].Length, otpl.Scopes[i].dumpEnterprise())
 	}
 	for i := range otpl.Options {
-		fmt.Fprintf(w, "\t\tOptionType(%v), Len(%v)\n", otpl.Options[i].Type, otpl.Options[i].Length)
+		fmt.Fprintf(w, "\t\tOptionType(%v), Len(%v)%s\n", otpl.Options[i].Type, otpl.Options[i].Length, otpl.Options[i].dumpEnterprise())
 	}
 }
+
+func (f *Field) dumpEnterprise() string {
+	if f.EnterpriseNumber == 0 {
+		return ""
+	}
+	return fmt.Sprintf(", PEN(%v)", f.EnterpriseNumber)
+}
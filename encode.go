@@ -0,0 +1,256 @@
+package nf9packet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	packetHeaderLength  = 20
+	flowSetHeaderLength = 4
+	fieldLength         = 4
+)
+
+func errorFlowSetTooLong(length int) error {
+	return fmt.Errorf("FlowSet length %d exceeds maximum of 65535 bytes.", length)
+}
+
+func errorReservedFlowSetId(id uint16) error {
+	return fmt.Errorf("Data FlowSet ID %d is reserved, IDs below 256 can not be used.", id)
+}
+
+func errorFieldCount(expected, actual int) error {
+	return fmt.Errorf("Record has %d values but template has %d fields.", actual, expected)
+}
+
+func errorFieldLength(field Field, actual int) error {
+	return fmt.Errorf("Value of %s has length %d, template expects %d.", field.Name(), actual, field.Length)
+}
+
+func errorRecordTooShort(length int) error {
+	return fmt.Errorf("Record length %d is less than 4 bytes, it can not be told apart from padding.", length)
+}
+
+func errorCountTooSmall(count uint16, sets int) error {
+	return fmt.Errorf("Packet Count %d is less than the number of FlowSets %d.", count, sets)
+}
+
+func paddingLength(length int) int {
+	return (4 - length%4) % 4
+}
+
+func writeFieldList(buf *bytes.Buffer, list []Field) {
+	for _, f := range list {
+		binary.Write(buf, binary.BigEndian, f.Type)
+		binary.Write(buf, binary.BigEndian, f.Length)
+	}
+}
+
+func writeFlowSet(buf *bytes.Buffer, id uint16, body []byte) error {
+	length := flowSetHeaderLength + len(body)
+	padding := paddingLength(length)
+	if length+padding > 0xffff {
+		return errorFlowSetTooLong(length + padding)
+	}
+
+	binary.Write(buf, binary.BigEndian, id)
+	binary.Write(buf, binary.BigEndian, uint16(length+padding))
+	buf.Write(body)
+	buf.Write(make([]byte, padding))
+	return nil
+}
+
+func (set *TemplateFlowSet) encode(buf *bytes.Buffer) error {
+	var body bytes.Buffer
+	for _, t := range set.Records {
+		binary.Write(&body, binary.BigEndian, t.TemplateId)
+		binary.Write(&body, binary.BigEndian, uint16(len(t.Fields)))
+		writeFieldList(&body, t.Fields)
+	}
+	return writeFlowSet(buf, 0, body.Bytes())
+}
+
+func (set *OptionsTemplateFlowSet) encode(buf *bytes.Buffer) error {
+	var body bytes.Buffer
+	for _, t := range set.Records {
+		binary.Write(&body, binary.BigEndian, t.TemplateId)
+		binary.Write(&body, binary.BigEndian, uint16(len(t.Scopes)*fieldLength))
+		binary.Write(&body, binary.BigEndian, uint16(len(t.Options)*fieldLength))
+		writeFieldList(&body, t.Scopes)
+		writeFieldList(&body, t.Options)
+	}
+	return writeFlowSet(buf, 1, body.Bytes())
+}
+
+func (set *DataFlowSet) encode(buf *bytes.Buffer) error {
+	if set.Id < 256 {
+		return errorReservedFlowSetId(set.Id)
+	}
+	return writeFlowSet(buf, set.Id, set.Data)
+}
+
+// Encode converts Packet struct to raw packet bytes, it is the reverse of
+// Decode. FlowSet lengths, template field counts and padding are calculated
+// from the actual FlowSet contents, values stored in the structures are
+// ignored. Packet Count is written as is, unless it is zero. In that case it is
+// set to the number of Template Records and Options Template Records plus the
+// number of Data FlowSets, as the number of records in Data FlowSet can not be
+// known without its template.
+func Encode(p *Packet) ([]byte, error) {
+	if p.Version != 9 {
+		return nil, fmt.Errorf("%w v%d, only v9 can be encoded.", ErrVersion, p.Version)
+	}
+
+	count := int(p.Count)
+	if count == 0 {
+		count = len(p.DataFlowSets()) + len(p.TemplateRecords()) + len(p.OptionsTemplateRecords())
+	}
+	if count < len(p.FlowSets) {
+		return nil, errorCountTooSmall(uint16(count), len(p.FlowSets))
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, packetHeaderLength))
+	binary.Write(buf, binary.BigEndian, p.Version)
+	binary.Write(buf, binary.BigEndian, uint16(count))
+	binary.Write(buf, binary.BigEndian, p.SysUpTime)
+	binary.Write(buf, binary.BigEndian, p.UnixSecs)
+	binary.Write(buf, binary.BigEndian, p.SequenceNumber)
+	binary.Write(buf, binary.BigEndian, p.SourceId)
+
+	for i := range p.FlowSets {
+		var err error
+		switch set := p.FlowSets[i].(type) {
+		case *DataFlowSet:
+			err = set.encode(buf)
+		case *TemplateFlowSet:
+			err = set.encode(buf)
+		case *OptionsTemplateFlowSet:
+			err = set.encode(buf)
+		default:
+			err = fmt.Errorf("Unsupported FlowSet type %T.", set)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler interface using Encode.
+func (p *Packet) MarshalBinary() ([]byte, error) {
+	return Encode(p)
+}
+
+// NewTemplateFlowSet creates a Template FlowSet containing given Template
+// Records. FieldCount of every record and FlowSet Length are filled in.
+func NewTemplateFlowSet(records ...TemplateRecord) *TemplateFlowSet {
+	set := &TemplateFlowSet{Records: append([]TemplateRecord(nil), records...)}
+	length := flowSetHeaderLength
+	for i := range set.Records {
+		set.Records[i].FieldCount = uint16(len(set.Records[i].Fields))
+		length += 4 + len(set.Records[i].Fields)*fieldLength
+	}
+	set.Id = 0
+	set.Length = uint16(length + paddingLength(length))
+	return set
+}
+
+// NewOptionsTemplateFlowSet creates an Options Template FlowSet containing
+// given Options Template Records. ScopeLength and OptionLength of every record
+// and FlowSet Length are filled in.
+func NewOptionsTemplateFlowSet(records ...OptionsTemplateRecord) *OptionsTemplateFlowSet {
+	set := &OptionsTemplateFlowSet{Records: append([]OptionsTemplateRecord(nil), records...)}
+	length := flowSetHeaderLength
+	for i := range set.Records {
+		t := &set.Records[i]
+		t.ScopeLength = uint16(len(t.Scopes) * fieldLength)
+		t.OptionLength = uint16(len(t.Options) * fieldLength)
+		length += 6 + int(t.ScopeLength) + int(t.OptionLength)
+	}
+	set.Id = 1
+	set.Length = uint16(length + paddingLength(length))
+	return set
+}
+
+// checkRecordLength rejects records shorter than 4 bytes. Decoder treats up
+// to 3 bytes at the end of Data FlowSet as padding, so padding after such
+// records would be decoded as one more record.
+func checkRecordLength(fields ...[]Field) error {
+	length := 0
+	for _, list := range fields {
+		for _, f := range list {
+			length += int(f.Length)
+		}
+	}
+	if length < 4 {
+		return errorRecordTooShort(length)
+	}
+	return nil
+}
+
+func encodeFieldValues(buf *bytes.Buffer, fields []Field, values [][]byte) error {
+	if len(values) != len(fields) {
+		return errorFieldCount(len(fields), len(values))
+	}
+	for i, f := range fields {
+		if len(values[i]) != int(f.Length) {
+			return errorFieldLength(f, len(values[i]))
+		}
+		buf.Write(values[i])
+	}
+	return nil
+}
+
+func newDataFlowSet(id uint16, buf *bytes.Buffer) (*DataFlowSet, error) {
+	length := flowSetHeaderLength + buf.Len()
+	padding := paddingLength(length)
+	if length+padding > 0xffff {
+		return nil, errorFlowSetTooLong(length + padding)
+	}
+	buf.Write(make([]byte, padding))
+
+	return &DataFlowSet{
+		FlowSetHeader: FlowSetHeader{id, uint16(length + padding)},
+		Data:          buf.Bytes(),
+	}, nil
+}
+
+// EncodeFlowSet uses current TemplateRecord to encode a list of Flow Data
+// Records to a Data FlowSet. It is the reverse of DecodeFlowSet. Every record
+// must have exactly one value of the right length for every template field.
+// Templates with records shorter than 4 bytes are rejected, as such records
+// can not be told apart from padding.
+func (dtpl *TemplateRecord) EncodeFlowSet(records []FlowDataRecord) (*DataFlowSet, error) {
+	if err := checkRecordLength(dtpl.Fields); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for i := range records {
+		if err := encodeFieldValues(&buf, dtpl.Fields, records[i].Values); err != nil {
+			return nil, err
+		}
+	}
+	return newDataFlowSet(dtpl.TemplateId, &buf)
+}
+
+// EncodeFlowSet uses current OptionsTemplateRecord to encode a list of Options
+// Data Records to a Data FlowSet. It is the reverse of DecodeFlowSet. Every
+// record must have exactly one value of the right length for every scope and
+// option field. Templates with records shorter than 4 bytes are rejected.
+func (otpl *OptionsTemplateRecord) EncodeFlowSet(records []OptionsDataRecord) (*DataFlowSet, error) {
+	if err := checkRecordLength(otpl.Scopes, otpl.Options); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for i := range records {
+		if err := encodeFieldValues(&buf, otpl.Scopes, records[i].ScopeValues); err != nil {
+			return nil, err
+		}
+		if err := encodeFieldValues(&buf, otpl.Options, records[i].OptionValues); err != nil {
+			return nil, err
+		}
+	}
+	return newDataFlowSet(otpl.TemplateId, &buf)
+}
@@ -0,0 +1,162 @@
+package nf9packet
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func randomFields(r *rand.Rand) []Field {
+	// Records shorter than 4 bytes can not be told apart from padding, so
+	// the first field is always long enough.
+	fields := make([]Field, 1+r.Intn(8))
+	for i := range fields {
+		fields[i] = Field{Type: uint16(1 + r.Intn(100)), Length: uint16(1 + r.Intn(16))}
+	}
+	fields[0].Length += 4
+	return fields
+}
+
+func randomValues(r *rand.Rand, fields []Field) [][]byte {
+	values := make([][]byte, len(fields))
+	for i, f := range fields {
+		values[i] = make([]byte, f.Length)
+		r.Read(values[i])
+	}
+	return values
+}
+
+func randomPacket(t *testing.T, r *rand.Rand) (*Packet, map[uint16][]FlowDataRecord) {
+	p := &Packet{
+		Version:        9,
+		SysUpTime:      r.Uint32(),
+		UnixSecs:       r.Uint32(),
+		SequenceNumber: r.Uint32(),
+		SourceId:       r.Uint32(),
+	}
+	records := make(map[uint16][]FlowDataRecord)
+	count := 0
+
+	for i := 0; i < 1+r.Intn(3); i++ {
+		var templates []TemplateRecord
+		for j := 0; j < 1+r.Intn(3); j++ {
+			tpl := TemplateRecord{TemplateId: uint16(256 + len(records)), Fields: randomFields(r)}
+			templates = append(templates, tpl)
+
+			var list []FlowDataRecord
+			for k := 0; k < 1+r.Intn(5); k++ {
+				list = append(list, FlowDataRecord{randomValues(r, tpl.Fields)})
+			}
+			records[tpl.TemplateId] = list
+		}
+		set := NewTemplateFlowSet(templates...)
+		p.FlowSets = append(p.FlowSets, set)
+		count += len(templates)
+
+		for _, tpl := range set.Records {
+			data, err := tpl.EncodeFlowSet(records[tpl.TemplateId])
+			require.NoError(t, err)
+			p.FlowSets = append(p.FlowSets, data)
+			count += len(records[tpl.TemplateId])
+		}
+	}
+
+	if r.Intn(2) == 0 {
+		tpl := OptionsTemplateRecord{
+			TemplateId: uint16(256 + len(records)),
+			Scopes:     randomFields(r),
+			Options:    randomFields(r),
+		}
+		p.FlowSets = append(p.FlowSets, NewOptionsTemplateFlowSet(tpl))
+		count++
+	}
+
+	p.Count = uint16(count)
+	return p, records
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 200; i++ {
+		p, records := randomPacket(t, r)
+
+		data, err := p.MarshalBinary()
+		require.NoError(t, err)
+		assert.Zero(t, len(data)%4)
+
+		actual, err := Decode(data)
+		require.NoError(t, err)
+		require.Equal(t, p, actual)
+
+		templates := actual.TemplateRecords()
+		for j, set := range actual.DataFlowSets() {
+			assert.Equal(t, records[set.Id], templates[j].DecodeFlowSet(set))
+		}
+	}
+}
+
+func TestEncodeHeader(t *testing.T) {
+	p, err := Decode(testTemplatePacket)
+	require.NoError(t, err)
+
+	data, err := Encode(p)
+	require.NoError(t, err)
+	assert.Equal(t, testTemplatePacket, data)
+}
+
+func TestEncodeErrors(t *testing.T) {
+	_, err := Encode(&Packet{Version: 5})
+	assert.Error(t, err)
+
+	_, err = Encode(&Packet{Version: 9, FlowSets: []FlowSet{&DataFlowSet{FlowSetHeader{1, 0}, nil}}})
+	assert.Error(t, err)
+
+	_, err = Encode(&Packet{Version: 9, Count: 1, FlowSets: []FlowSet{&DataFlowSet{}, &DataFlowSet{}}})
+	assert.Error(t, err)
+
+	tpl := TemplateRecord{256, 1, []Field{{Type: 8, Length: 4}}}
+	_, err = tpl.EncodeFlowSet([]FlowDataRecord{{[][]byte{{1, 2, 3}}}})
+	assert.Error(t, err)
+	_, err = tpl.EncodeFlowSet([]FlowDataRecord{{[][]byte{{1, 2, 3, 4}, {5}}}})
+	assert.Error(t, err)
+}
+
+func TestEncodeShortRecords(t *testing.T) {
+	// 3 records of 2 bytes would be padded to 8 bytes and decoded as 4
+	// records.
+	tpl := TemplateRecord{256, 1, []Field{{Type: 7, Length: 2}}}
+	records := []FlowDataRecord{{[][]byte{{0, 1}}}, {[][]byte{{0, 2}}}, {[][]byte{{0, 3}}}}
+	_, err := tpl.EncodeFlowSet(records)
+	assert.Error(t, err)
+
+	otpl := OptionsTemplateRecord{TemplateId: 257, Scopes: []Field{{Type: 1, Length: 1}}, Options: []Field{{Type: 7, Length: 2}}}
+	_, err = otpl.EncodeFlowSet([]OptionsDataRecord{{[][]byte{{0}}, [][]byte{{0, 1}}}})
+	assert.Error(t, err)
+
+	var w packetRecorder
+	e := NewExporter(&w, 1)
+	e.AddTemplate(tpl)
+	assert.Error(t, e.WriteRecords(256, records...))
+
+	// 4 byte records survive the round trip whatever their count.
+	tpl = TemplateRecord{258, 2, []Field{{Type: 7, Length: 2}, {Type: 11, Length: 2}}}
+	for n := 1; n <= 4; n++ {
+		var records []FlowDataRecord
+		for i := 0; i < n; i++ {
+			records = append(records, FlowDataRecord{[][]byte{{0, byte(i)}, {1, byte(i)}}})
+		}
+		set, err := tpl.EncodeFlowSet(records)
+		require.NoError(t, err)
+		data, err := Encode(&Packet{Version: 9, FlowSets: []FlowSet{NewTemplateFlowSet(tpl), set}})
+		require.NoError(t, err)
+
+		p, err := Decode(data)
+		require.NoError(t, err)
+		decoded, err := p.TemplateRecords()[0].DecodeRecords(p.DataFlowSets()[0])
+		require.NoError(t, err)
+		assert.Equal(t, records, decoded)
+	}
+}
@@ -0,0 +1,155 @@
+package nf9packet
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors reported while decoding packets. Decode functions wrap them in
+// DecodeError, use errors.Is to check for a particular problem and errors.As
+// to get the details.
+var (
+	// Packet or FlowSet ends before all the announced data.
+	ErrShortPacket = errors.New("Incomplete packet")
+
+	// Protocol version is not supported by the decode function.
+	ErrVersion = errors.New("Unsupported protocol version")
+
+	// Packet contains data after the last FlowSet.
+	ErrTrailingBytes = errors.New("Extra bytes at the end of the packet")
+
+	// FlowSet (or IPFIX Set, or IPFIX message) length is shorter than its
+	// header.
+	ErrBadFlowSetLength = errors.New("Invalid FlowSet length")
+
+	// Template Record or Options Template Record is inconsistent.
+	ErrBadTemplate = errors.New("Invalid template")
+
+	// Field value length does not match field type.
+	ErrValueLength = errors.New("Invalid field value length")
+
+	// Template with the given ID is not known.
+	ErrUnknownTemplate = errors.New("Unknown template")
+
+	// Data FlowSet ID does not match Template ID of the template used to
+	// decode it.
+	ErrTemplateMismatch = errors.New("Template mismatch")
+
+	// Data FlowSet ends in the middle of a record.
+	ErrTruncatedRecord = errors.New("Truncated record")
+
+	// IPFIX Set ID is reserved by RFC 7011 (0, 1 and 4-255).
+	ErrReservedSetId = errors.New("Reserved Set ID")
+)
+
+// DecodeError describes a problem found while decoding a packet.
+type DecodeError struct {
+	// One of the Err* sentinel errors.
+	Err error
+
+	// Offset in bytes from the start of the packet where the problem was
+	// detected. For ErrTruncatedRecord it is the offset of the record from
+	// the start of data of Data FlowSet FlowSetId.
+	Offset int
+
+	// Index of the FlowSet the problem was found in, -1 if the problem is
+	// not related to a particular FlowSet or the FlowSet was decoded
+	// outside of its packet. Session fills it in for Data FlowSets of the
+	// decoded packet.
+	FlowSet int
+
+	// Number of missing bytes for ErrShortPacket, number of extra bytes
+	// for ErrTrailingBytes, invalid length for ErrBadFlowSetLength and
+	// ErrValueLength and number of bytes left for ErrTruncatedRecord.
+	Bytes int
+
+	// Protocol version for ErrVersion.
+	Version uint16
+
+	// Data FlowSet ID for ErrTemplateMismatch and ErrTruncatedRecord.
+	FlowSetId uint16
+
+	// Template ID for ErrTemplateMismatch and for ErrBadTemplate returned
+	// by TemplateRecord.Compile.
+	TemplateId uint16
+
+	// Valid value lengths for ErrValueLength, reason the template can not
+	// be compiled for ErrBadTemplate returned by TemplateRecord.Compile.
+	Reason string
+}
+
+// Error returns description of the problem.
+func (e *DecodeError) Error() string {
+	var msg string
+	switch e.Err {
+	case ErrShortPacket:
+		msg = fmt.Sprintf("Incomplete packet, missing at least %d bytes at offset %d", e.Bytes, e.Offset)
+	case ErrVersion:
+		msg = fmt.Sprintf("Unsupported protocol version v%d", e.Version)
+	case ErrTrailingBytes:
+		msg = fmt.Sprintf("Extra %d bytes at the end of the packet at offset %d", e.Bytes, e.Offset)
+	case ErrBadFlowSetLength:
+		msg = fmt.Sprintf("Invalid FlowSet length %d at offset %d", e.Bytes, e.Offset)
+	case ErrTruncatedRecord:
+		msg = fmt.Sprintf("Truncated record of %d bytes at offset %d of Data FlowSet %d", e.Bytes, e.Offset, e.FlowSetId)
+	case ErrTemplateMismatch:
+		msg = fmt.Sprintf("Template mismatch, Data FlowSet ID %d does not match template ID %d", e.FlowSetId, e.TemplateId)
+	case ErrValueLength:
+		msg = fmt.Sprintf("Invalid field value length %d, expected %s bytes", e.Bytes, e.Reason)
+	default:
+		if e.Reason != "" {
+			msg = fmt.Sprintf("%v %d, %s", e.Err, e.TemplateId, e.Reason)
+		} else {
+			msg = fmt.Sprintf("%v at offset %d", e.Err, e.Offset)
+		}
+	}
+	if e.FlowSet >= 0 {
+		msg += fmt.Sprintf(" in FlowSet %d", e.FlowSet)
+	}
+	return msg + "."
+}
+
+// Unwrap returns the underlying sentinel error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+func errorMissingData(offset, flowSet, bytes int) error {
+	return &DecodeError{Err: ErrShortPacket, Offset: offset, FlowSet: flowSet, Bytes: bytes}
+}
+
+func errorIncompatibleVersion(version uint16) error {
+	return &DecodeError{Err: ErrVersion, FlowSet: -1, Version: version}
+}
+
+func errorExtraBytes(offset, bytes int) error {
+	return &DecodeError{Err: ErrTrailingBytes, Offset: offset, FlowSet: -1, Bytes: bytes}
+}
+
+func errorBadFlowSetLength(offset, flowSet int, length int) error {
+	return &DecodeError{Err: ErrBadFlowSetLength, Offset: offset, FlowSet: flowSet, Bytes: length}
+}
+
+func errorBadTemplate(offset, flowSet int) error {
+	return &DecodeError{Err: ErrBadTemplate, Offset: offset, FlowSet: flowSet}
+}
+
+func errorTemplateMismatch(setId, templateId uint16) error {
+	return &DecodeError{Err: ErrTemplateMismatch, FlowSet: -1, FlowSetId: setId, TemplateId: templateId}
+}
+
+func errorTruncatedRecord(setId uint16, offset, bytes int) error {
+	return &DecodeError{Err: ErrTruncatedRecord, Offset: offset, FlowSet: -1, Bytes: bytes, FlowSetId: setId}
+}
+
+func errorValueLength(actual int, expected string) error {
+	return &DecodeError{Err: ErrValueLength, FlowSet: -1, Bytes: actual, Reason: expected}
+}
+
+func errorNotCompilable(templateId uint16, reason string) error {
+	return &DecodeError{Err: ErrBadTemplate, FlowSet: -1, TemplateId: templateId, Reason: reason}
+}
+
+func errorReservedSetId(offset, flowSet int) error {
+	return &DecodeError{Err: ErrReservedSetId, Offset: offset, FlowSet: flowSet}
+}
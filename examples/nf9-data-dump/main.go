@@ -1,17 +1,18 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"net"
 	"os"
+	"os/signal"
 	"strconv"
 
 	"github.com/fln/nf9packet"
+	"github.com/fln/nf9packet/collector"
 )
 
-type templateCache map[string]*nf9packet.TemplateRecord
-
 func printTable(template *nf9packet.TemplateRecord, records []nf9packet.FlowDataRecord) {
 	fmt.Printf("|")
 	for _, f := range template.Fields {
@@ -29,35 +30,9 @@ func printTable(template *nf9packet.TemplateRecord, records []nf9packet.FlowData
 	}
 }
 
-func packetDump(addr string, data []byte, cache templateCache) {
-	p, err := nf9packet.Decode(data)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		return
-	}
-
-	templateList := p.TemplateRecords()
-	flowSets := p.DataFlowSets()
-
-	for _, t := range templateList {
-		templateKey := fmt.Sprintf("%s|%b|%v", addr, p.SourceId, t.TemplateId)
-		cache[templateKey] = t
-	}
-
-	for _, set := range flowSets {
-		templateKey := fmt.Sprintf("%s|%b|%v", addr, p.SourceId, set.Id)
-		template, ok := cache[templateKey]
-		if !ok {
-			// We do not have template for this Data FlowSet yet
-			continue
-		}
-
-		records := template.DecodeFlowSet(&set)
-		if records == nil {
-			// Error in decoding Data FlowSet
-			continue
-		}
-		printTable(template, records)
+func packetDump(addr net.Addr, p *nf9packet.SessionPacket) {
+	for _, flows := range p.Flows {
+		printTable(flows.Template, flows.Records)
 	}
 }
 
@@ -65,25 +40,18 @@ func main() {
 	listenAddr := flag.String("listen", ":9995", "Address to listen for NetFlow v9 packets.")
 	flag.Parse()
 
-	addr, err := net.ResolveUDPAddr("udp", *listenAddr)
-	if err != nil {
-		panic(err)
-	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-	con, err := net.ListenUDP("udp", addr)
-	if err != nil {
-		panic(err)
+	c := collector.New(collector.HandlerFunc(packetDump), *listenAddr)
+	// Tables of concurrent workers would be mixed up.
+	c.Workers = 1
+	c.OnError = func(addr net.Addr, err error) {
+		fmt.Fprintln(os.Stderr, err)
 	}
 
-	data := make([]byte, 8960)
-	cache := make(templateCache)
-
-	for {
-		length, remote, err := con.ReadFrom(data)
-		if err != nil {
-			panic(err)
-		}
-
-		packetDump(remote.String(), data[:length], cache)
+	if err := c.ListenAndServe(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 }
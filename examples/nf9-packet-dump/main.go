@@ -1,31 +1,28 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"net"
 	"os"
+	"os/signal"
 
 	"github.com/fln/nf9packet"
+	"github.com/fln/nf9packet/collector"
 )
 
 var dumpJSON bool
 
-func packetDump(addr net.Addr, data []byte) {
+func packetDump(addr net.Addr, p *nf9packet.SessionPacket) {
 	fmt.Fprintln(os.Stderr, "Got packet from: ", addr)
-	p, err := nf9packet.Decode(data)
-
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		return
-	}
 
 	if dumpJSON {
-		json, _ := json.MarshalIndent(p, "", "\t")
+		json, _ := json.MarshalIndent(p.Packet, "", "\t")
 		fmt.Printf("%s\n", json)
 	} else {
-		nf9packet.Dump(p, os.Stdout)
+		nf9packet.Dump(p.Packet, os.Stdout)
 		fmt.Print("\n")
 	}
 }
@@ -35,23 +32,18 @@ func main() {
 	flag.BoolVar(&dumpJSON, "json", false, "Dump packet in JSON instead of plain text.")
 	flag.Parse()
 
-	addr, err := net.ResolveUDPAddr("udp", *listenAddr)
-	if err != nil {
-		panic(err)
-	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-	con, err := net.ListenUDP("udp", addr)
-	if err != nil {
-		panic(err)
+	c := collector.New(collector.HandlerFunc(packetDump), *listenAddr)
+	// Dumps of concurrent workers would be mixed up.
+	c.Workers = 1
+	c.OnError = func(addr net.Addr, err error) {
+		fmt.Fprintln(os.Stderr, err)
 	}
 
-	data := make([]byte, 8960)
-	for {
-		length, remote, err := con.ReadFrom(data)
-		if err != nil {
-			panic(err)
-		}
-
-		packetDump(remote, data[:length])
+	if err := c.ListenAndServe(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 }
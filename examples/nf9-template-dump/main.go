@@ -1,21 +1,18 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"net"
 	"os"
+	"os/signal"
 
 	"github.com/fln/nf9packet"
+	"github.com/fln/nf9packet/collector"
 )
 
-func packetDump(addr net.Addr, data []byte) {
-	p, err := nf9packet.Decode(data)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		return
-	}
-
+func packetDump(addr net.Addr, p *nf9packet.SessionPacket) {
 	templateList := p.TemplateRecords()
 	optTemplateList := p.OptionsTemplateRecords()
 
@@ -46,23 +43,18 @@ func main() {
 	listenAddr := flag.String("listen", ":9995", "Address to listen for NetFlow v9 packets.")
 	flag.Parse()
 
-	addr, err := net.ResolveUDPAddr("udp", *listenAddr)
-	if err != nil {
-		panic(err)
-	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-	con, err := net.ListenUDP("udp", addr)
-	if err != nil {
-		panic(err)
+	c := collector.New(collector.HandlerFunc(packetDump), *listenAddr)
+	// Dumps of concurrent workers would be mixed up.
+	c.Workers = 1
+	c.OnError = func(addr net.Addr, err error) {
+		fmt.Fprintln(os.Stderr, err)
 	}
 
-	data := make([]byte, 8960)
-	for {
-		length, remote, err := con.ReadFrom(data)
-		if err != nil {
-			panic(err)
-		}
-
-		packetDump(remote, data[:length])
+	if err := c.ListenAndServe(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,405 @@
+package nf9packet
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+func errorUnknownTemplate(id uint16) error {
+	return fmt.Errorf("%w ID %d.", ErrUnknownTemplate, id)
+}
+
+func errorRecordTooLong(length, mtu int) error {
+	return fmt.Errorf("Record of %d bytes does not fit in a %d byte packet.", length, mtu)
+}
+
+type packetConnWriter struct {
+	conn net.PacketConn
+	addr net.Addr
+}
+
+func (w *packetConnWriter) Write(b []byte) (int, error) {
+	return w.conn.WriteTo(b, w.addr)
+}
+
+// Exporter batches Flow Data Records and Options Data Records into NetFlow v9
+// packets and writes them to an io.Writer, one packet per Write call. It
+// manages packet SequenceNumber and SysUpTime and periodically re-sends
+// Template Records and Options Template Records as recommended by RFC 3954.
+// Exporter is safe for concurrent use by multiple goroutines.
+type Exporter struct {
+	// Maximum size of a single packet in bytes.
+	MTU int
+
+	// Templates are re-sent after this many packets. Zero disables packet
+	// count based refresh.
+	TemplateRefreshPackets int
+
+	// Templates are re-sent if they were not sent for this long. Zero
+	// disables time based refresh.
+	TemplateRefreshInterval time.Duration
+
+	mu       sync.Mutex
+	w        io.Writer
+	sourceId uint32
+	sequence uint32
+	boot     time.Time
+	now      func() time.Time
+
+	templates []TemplateRecord
+	options   []OptionsTemplateRecord
+
+	refreshNeeded  bool
+	lastRefresh    time.Time
+	packetsSent    int
+	packetsRefresh int
+
+	// Packet being built.
+	packet  *Packet
+	size    int
+	setId   uint16
+	setData bytes.Buffer
+}
+
+// NewExporter creates an exporter writing packets with the given SourceId to
+// w. SysUpTime of exported packets is counted from the exporter creation.
+func NewExporter(w io.Writer, sourceId uint32) *Exporter {
+	e := &Exporter{
+		MTU:                     1400,
+		TemplateRefreshPackets:  20,
+		TemplateRefreshInterval: 30 * time.Minute,
+		w:                       w,
+		sourceId:                sourceId,
+		now:                     time.Now,
+	}
+	e.boot = e.now()
+	return e
+}
+
+// NewPacketConnExporter creates an exporter sending packets to addr using a
+// non-connected packet connection like the one returned by net.ListenUDP.
+func NewPacketConnExporter(conn net.PacketConn, addr net.Addr, sourceId uint32) *Exporter {
+	return NewExporter(&packetConnWriter{conn, addr}, sourceId)
+}
+
+// AddTemplate registers Template Record t. Template with the same ID is
+// replaced. Templates are sent with the next packet.
+func (e *Exporter) AddTemplate(t TemplateRecord) {
+	t.Fields = copyFields(t.Fields)
+	t.FieldCount = uint16(len(t.Fields))
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.removeTemplate(t.TemplateId)
+	e.templates = append(e.templates, t)
+	e.refreshNeeded = true
+}
+
+// AddOptionsTemplate registers Options Template Record t. Template with the
+// same ID is replaced. Templates are sent with the next packet.
+func (e *Exporter) AddOptionsTemplate(t OptionsTemplateRecord) {
+	t.Scopes = copyFields(t.Scopes)
+	t.Options = copyFields(t.Options)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.removeTemplate(t.TemplateId)
+	e.options = append(e.options, t)
+	e.refreshNeeded = true
+}
+
+func (e *Exporter) removeTemplate(id uint16) {
+	for i := range e.templates {
+		if e.templates[i].TemplateId == id {
+			e.templates = append(e.templates[:i], e.templates[i+1:]...)
+			return
+		}
+	}
+	for i := range e.options {
+		if e.options[i].TemplateId == id {
+			e.options = append(e.options[:i], e.options[i+1:]...)
+			return
+		}
+	}
+}
+
+func (e *Exporter) template(id uint16) *TemplateRecord {
+	for i := range e.templates {
+		if e.templates[i].TemplateId == id {
+			return &e.templates[i]
+		}
+	}
+	return nil
+}
+
+func (e *Exporter) optionsTemplate(id uint16) *OptionsTemplateRecord {
+	for i := range e.options {
+		if e.options[i].TemplateId == id {
+			return &e.options[i]
+		}
+	}
+	return nil
+}
+
+// WriteRecords adds Flow Data Records encoded using template templateId to
+// the packet being built. Full packets are written out as needed.
+func (e *Exporter) WriteRecords(templateId uint16, records ...FlowDataRecord) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	t := e.template(templateId)
+	if t == nil {
+		return errorUnknownTemplate(templateId)
+	}
+
+	var buf bytes.Buffer
+	for i := range records {
+		buf.Reset()
+		if err := encodeFieldValues(&buf, t.Fields, records[i].Values); err != nil {
+			return err
+		}
+		if err := e.addRecord(templateId, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteOptionsRecords adds Options Data Records encoded using options
+// template templateId to the packet being built. Full packets are written out
+// as needed.
+func (e *Exporter) WriteOptionsRecords(templateId uint16, records ...OptionsDataRecord) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	t := e.optionsTemplate(templateId)
+	if t == nil {
+		return errorUnknownTemplate(templateId)
+	}
+
+	var buf bytes.Buffer
+	for i := range records {
+		buf.Reset()
+		if err := encodeFieldValues(&buf, t.Scopes, records[i].ScopeValues); err != nil {
+			return err
+		}
+		if err := encodeFieldValues(&buf, t.Options, records[i].OptionValues); err != nil {
+			return err
+		}
+		if err := e.addRecord(templateId, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush writes out the packet being built, if there is one.
+func (e *Exporter) Flush() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.flush()
+}
+
+// SendTemplates writes out the packet being built and sends all templates
+// immediately.
+func (e *Exporter) SendTemplates() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.flush(); err != nil {
+		return err
+	}
+	e.refreshNeeded = true
+	if err := e.startPacket(); err != nil {
+		return err
+	}
+	return e.flush()
+}
+
+func (e *Exporter) refreshDue() bool {
+	switch {
+	case e.refreshNeeded:
+		return true
+	case e.TemplateRefreshPackets > 0 && e.packetsSent-e.packetsRefresh >= e.TemplateRefreshPackets:
+		return true
+	case e.TemplateRefreshInterval > 0 && e.now().Sub(e.lastRefresh) >= e.TemplateRefreshInterval:
+		return true
+	default:
+		return false
+	}
+}
+
+func (e *Exporter) newPacket() {
+	e.packet = &Packet{Version: 9, SourceId: e.sourceId}
+	e.size = packetHeaderLength
+}
+
+func paddedLength(length int) int {
+	return length + paddingLength(length)
+}
+
+// startPacket begins a new packet, adding templates to it if refresh is due.
+// Templates that do not fit in a single packet are spread over several.
+func (e *Exporter) startPacket() error {
+	e.newPacket()
+	if !e.refreshDue() {
+		return nil
+	}
+
+	var templates []TemplateRecord
+	length := flowSetHeaderLength
+	for _, t := range e.templates {
+		recordLength := 4 + len(t.Fields)*fieldLength
+		if e.size+paddedLength(length+recordLength) > e.MTU {
+			if len(templates) == 0 {
+				return errorRecordTooLong(recordLength, e.MTU)
+			}
+			e.addFlowSet(NewTemplateFlowSet(templates...), len(templates), length)
+			if err := e.flush(); err != nil {
+				return err
+			}
+			e.newPacket()
+			templates, length = nil, flowSetHeaderLength
+		}
+		templates = append(templates, t)
+		length += recordLength
+	}
+	if len(templates) > 0 {
+		e.addFlowSet(NewTemplateFlowSet(templates...), len(templates), length)
+	}
+
+	var options []OptionsTemplateRecord
+	length = flowSetHeaderLength
+	for _, t := range e.options {
+		recordLength := 6 + (len(t.Scopes)+len(t.Options))*fieldLength
+		if e.size+paddedLength(length+recordLength) > e.MTU {
+			if len(options) > 0 {
+				e.addFlowSet(NewOptionsTemplateFlowSet(options...), len(options), length)
+			} else if len(e.packet.FlowSets) == 0 {
+				return errorRecordTooLong(recordLength, e.MTU)
+			}
+			if err := e.flush(); err != nil {
+				return err
+			}
+			e.newPacket()
+			options, length = nil, flowSetHeaderLength
+		}
+		options = append(options, t)
+		length += recordLength
+	}
+	if len(options) > 0 {
+		e.addFlowSet(NewOptionsTemplateFlowSet(options...), len(options), length)
+	}
+
+	e.refreshNeeded = false
+	e.lastRefresh = e.now()
+	e.packetsRefresh = e.packetsSent
+	return nil
+}
+
+func (e *Exporter) addFlowSet(set FlowSet, records int, length int) {
+	e.packet.FlowSets = append(e.packet.FlowSets, set)
+	e.packet.Count += uint16(records)
+	e.size += paddedLength(length)
+}
+
+func (e *Exporter) closeDataFlowSet() {
+	if e.setData.Len() == 0 {
+		return
+	}
+	length := flowSetHeaderLength + e.setData.Len()
+	e.setData.Write(make([]byte, paddingLength(length)))
+	e.packet.FlowSets = append(e.packet.FlowSets, &DataFlowSet{
+		FlowSetHeader: FlowSetHeader{e.setId, uint16(paddedLength(length))},
+		Data:          append([]byte(nil), e.setData.Bytes()...),
+	})
+	e.size += paddedLength(length)
+	e.setData.Reset()
+}
+
+// pendingSize returns packet size after adding a record of the given length
+// to Data FlowSet setId.
+func (e *Exporter) pendingSize(setId uint16, length int) int {
+	size := e.size
+	current := e.setData.Len()
+	if current > 0 && e.setId != setId {
+		size += paddedLength(flowSetHeaderLength + current)
+		current = 0
+	}
+	return size + paddedLength(flowSetHeaderLength+current+length)
+}
+
+func (e *Exporter) addRecord(setId uint16, record []byte) error {
+	if len(record) < 4 {
+		return errorRecordTooShort(len(record))
+	}
+	if packetHeaderLength+flowSetHeaderLength+len(record) > e.MTU {
+		return errorRecordTooLong(len(record), e.MTU)
+	}
+
+	if e.packet == nil || e.refreshDue() {
+		if err := e.flush(); err != nil {
+			return err
+		}
+		if err := e.startPacket(); err != nil {
+			return err
+		}
+	}
+	if e.pendingSize(setId, len(record)) > e.MTU {
+		if err := e.flush(); err != nil {
+			return err
+		}
+		if err := e.startPacket(); err != nil {
+			return err
+		}
+		if e.pendingSize(setId, len(record)) > e.MTU {
+			// Templates took too much space, send them alone.
+			if err := e.flush(); err != nil {
+				return err
+			}
+			e.newPacket()
+		}
+	}
+
+	if e.setData.Len() > 0 && e.setId != setId {
+		e.closeDataFlowSet()
+	}
+	e.setId = setId
+	e.setData.Write(record)
+	e.packet.Count++
+	return nil
+}
+
+func (e *Exporter) flush() error {
+	if e.packet == nil {
+		return nil
+	}
+	e.closeDataFlowSet()
+
+	p := e.packet
+	e.packet = nil
+	if len(p.FlowSets) == 0 {
+		return nil
+	}
+
+	now := e.now()
+	p.SysUpTime = uint32(now.Sub(e.boot) / time.Millisecond)
+	p.UnixSecs = uint32(now.Unix())
+	p.SequenceNumber = e.sequence
+
+	data, err := Encode(p)
+	if err != nil {
+		return err
+	}
+	if _, err := e.w.Write(data); err != nil {
+		return err
+	}
+
+	e.sequence++
+	e.packetsSent++
+	return nil
+}
@@ -0,0 +1,128 @@
+package nf9packet
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type packetRecorder struct {
+	packets [][]byte
+}
+
+func (r *packetRecorder) Write(b []byte) (int, error) {
+	r.packets = append(r.packets, append([]byte(nil), b...))
+	return len(b), nil
+}
+
+var testExporterTemplate = TemplateRecord{
+	TemplateId: 256,
+	Fields:     []Field{{Type: 8, Length: 4}, {Type: 12, Length: 4}, {Type: 1, Length: 4}},
+}
+
+func testExporterRecord(i int) FlowDataRecord {
+	return FlowDataRecord{[][]byte{
+		{10, 0, 0, byte(i)},
+		{10, 0, 1, byte(i)},
+		{0, 0, byte(i >> 8), byte(i)},
+	}}
+}
+
+func TestExporterBatching(t *testing.T) {
+	var w packetRecorder
+	e := NewExporter(&w, 42)
+	e.MTU = 200
+	e.TemplateRefreshPackets = 3
+	e.AddTemplate(testExporterTemplate)
+
+	assert.Error(t, e.WriteRecords(300, testExporterRecord(0)))
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, e.WriteRecords(256, testExporterRecord(i)))
+	}
+	require.NoError(t, e.Flush())
+	require.NotEmpty(t, w.packets)
+
+	s := NewSession()
+	var records []FlowDataRecord
+	for i, data := range w.packets {
+		assert.True(t, len(data) <= e.MTU)
+
+		p, err := s.Decode("exporter", data)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(i), p.SequenceNumber)
+		assert.Equal(t, uint32(42), p.SourceId)
+		assert.Equal(t, i%3 == 0, len(p.TemplateRecords()) == 1, "packet %d", i)
+
+		count := len(p.TemplateRecords())
+		for _, f := range p.Flows {
+			records = append(records, f.Records...)
+			count += len(f.Records)
+		}
+		assert.Equal(t, int(p.Count), count)
+	}
+
+	require.Len(t, records, 100)
+	for i := range records {
+		assert.Equal(t, testExporterRecord(i), records[i])
+	}
+}
+
+func TestExporterTimeRefresh(t *testing.T) {
+	var w packetRecorder
+	now := time.Unix(1000, 0)
+
+	e := NewExporter(&w, 1)
+	e.now = func() time.Time { return now }
+	e.boot = now
+	e.TemplateRefreshPackets = 0
+	e.TemplateRefreshInterval = time.Minute
+	e.AddTemplate(testExporterTemplate)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, e.WriteRecords(256, testExporterRecord(i)))
+		require.NoError(t, e.Flush())
+		now = now.Add(40 * time.Second)
+	}
+
+	var withTemplates []bool
+	for _, data := range w.packets {
+		p, err := Decode(data)
+		require.NoError(t, err)
+		withTemplates = append(withTemplates, len(p.TemplateRecords()) > 0)
+	}
+	assert.Equal(t, []bool{true, false, true}, withTemplates)
+
+	p, err := Decode(w.packets[2])
+	require.NoError(t, err)
+	assert.Equal(t, uint32(80000), p.SysUpTime)
+	assert.Equal(t, uint32(1080), p.UnixSecs)
+}
+
+func TestExporterUDP(t *testing.T) {
+	collector, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer collector.Close()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	e := NewPacketConnExporter(conn, collector.LocalAddr(), 7)
+	e.AddTemplate(testExporterTemplate)
+	require.NoError(t, e.WriteRecords(256, testExporterRecord(1), testExporterRecord(2)))
+	require.NoError(t, e.Flush())
+
+	buf := make([]byte, 8960)
+	collector.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, addr, err := collector.ReadFrom(buf)
+	require.NoError(t, err)
+
+	p, err := NewSession().Decode(addr.String(), buf[:n])
+	require.NoError(t, err)
+	require.Len(t, p.Flows, 1)
+	assert.Equal(t, []FlowDataRecord{testExporterRecord(1), testExporterRecord(2)}, p.Flows[0].Records)
+}
@@ -9,8 +9,26 @@ type Field struct {
 	// A numeric value that represents the type of field.
 	Type uint16
 
-	// The length (in bytes) of the field.
+	// The length (in bytes) of the field. For IPFIX variable length fields
+	// it is set to VariableLength.
 	Length uint16
+
+	// IANA Private Enterprise Number of enterprise-specific IPFIX Information
+	// Elements. For NetFlow v9 fields and IANA defined IPFIX Information
+	// Elements it is zero.
+	EnterpriseNumber uint32
+}
+
+// VariableLength is the Field.Length value of IPFIX variable length fields.
+// Actual value length is encoded in front of every value in Data Records.
+const VariableLength = 0xffff
+
+func (f *Field) dbEntry() (fieldDbEntry, bool) {
+	if f.EnterpriseNumber != 0 {
+		return fieldDbEntry{}, false
+	}
+	e, ok := fieldDb[f.Type]
+	return e, ok
 }
 
 // String is a convenience method for printing field name as a default field
@@ -21,17 +39,21 @@ func (f Field) String() string {
 
 // Name returns a short field type identifier based on RFC 3954 and Cisco
 // documentation. For unkown field types string "UNKNOWN_TYPE" will be returned.
+// Enterprise-specific IPFIX fields are named "ENTERPRISE_<PEN>_<type>".
 func (f *Field) Name() string {
-	if e, ok := fieldDb[f.Type]; ok {
+	if e, ok := f.dbEntry(); ok {
 		return e.Name
 	}
+	if f.EnterpriseNumber != 0 {
+		return fmt.Sprintf("ENTERPRISE_%d_%d", f.EnterpriseNumber, f.Type)
+	}
 	return fmt.Sprintf("UNKNOWN_TYPE_%d", f.Type)
 }
 
 // DefaultLength returns length of field type as specified in RFC 3954 and Cisco
 // documentation. For variable length fields and unknown fields -1 is returned.
 func (f *Field) DefaultLength() int {
-	if e, ok := fieldDb[f.Type]; ok {
+	if e, ok := f.dbEntry(); ok {
 		return e.Length
 	}
 	return -1
@@ -40,16 +62,19 @@ func (f *Field) DefaultLength() int {
 // Description returns field type description based on RFC 3954 and Cisco
 // documentation. For unkown field types string "Unknown type" will be returned.
 func (f *Field) Description() string {
-	if e, ok := fieldDb[f.Type]; ok {
+	if e, ok := f.dbEntry(); ok {
 		return e.Description
 	}
+	if f.EnterpriseNumber != 0 {
+		return fmt.Sprintf("Enterprise-specific type (%d, PEN %d)", f.Type, f.EnterpriseNumber)
+	}
 	return fmt.Sprintf("Unknown type (%d)", f.Type)
 }
 
 // DataToString converts field value to string representation based on field
 // type. If used with unknow field type string "n/a" will be returned.
 func (f *Field) DataToString(data []byte) string {
-	if e, ok := fieldDb[f.Type]; ok {
+	if e, ok := f.dbEntry(); ok {
 		return e.String(data)
 	}
 	return "n/a"
@@ -65,15 +90,15 @@ func (f *Field) DataToUint64(data []byte) uint64 {
 // ScopeName is the same as Name() but should be used only for Scope Fields
 func (f *Field) ScopeName() string {
 	switch f.Type {
-	case 1:
+	case ScopeSystem:
 		return "System"
-	case 2:
+	case ScopeInterface:
 		return "Interface"
-	case 3:
+	case ScopeLineCard:
 		return "Line Card"
-	case 4:
+	case ScopeCache:
 		return "Cache"
-	case 5:
+	case ScopeTemplate:
 		return "Template"
 	default:
 		return "Unknown"
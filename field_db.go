@@ -2,7 +2,6 @@ package nf9packet
 
 import (
 	"encoding/hex"
-	"fmt"
 	"net"
 	"strconv"
 	"time"
@@ -12,106 +11,154 @@ type fieldDbEntry struct {
 	Name        string
 	Length      int
 	String      func(bytes []uint8) string
+	Value       func(bytes []uint8) (Value, error)
 	Description string
 }
 
 var fieldDb = map[uint16]fieldDbEntry{
-	1:  fieldDbEntry{"IN_BYTES", -1, fieldToStringUInteger, "Incoming counter with length N x 8 bits for the number of bytes associated with an IP Flow. By default N is 4."},
-	2:  fieldDbEntry{"IN_PKTS", -1, fieldToStringUInteger, "Incoming counter with length N x 8 bits for the number of packes associated with an IP Flow. By default N is 4."},
-	3:  fieldDbEntry{"FLOWS", -1, fieldToStringUInteger, "Number of Flows that were aggregated; by default N is 4."},
-	4:  fieldDbEntry{"PROTOCOL", 1, fieldToStringHex, "IP protocol byte."},
-	5:  fieldDbEntry{"SRC_TOS", 1, fieldToStringHex, "Type of service byte setting when entering the incoming interface."},
-	6:  fieldDbEntry{"TCP_FLAGS", 1, fieldToStringTCPFlags, "TCP flags; cumulative of all the TCP flags seen in this Flow."},
-	7:  fieldDbEntry{"L4_SRC_PORT", 2, fieldToStringUInteger, "TCP/UDP source port number (for example, FTP, Telnet, or equivalent)."},
-	8:  fieldDbEntry{"IPV4_SRC_ADDR", 4, fieldToStringIP, "IPv4 source address."},
-	9:  fieldDbEntry{"SRC_MASK", 1, fieldToStringUInteger, "The number of contiguous bits in the source subnet mask (i.e., the mask in slash notation)."},
-	10: fieldDbEntry{"INPUT_SNMP", -1, fieldToStringUInteger, "Input interface index. By default N is 2, but higher values can be used."},
-	11: fieldDbEntry{"L4_DST_PORT", 2, fieldToStringUInteger, "TCP/UDP destination port number (for example, FTP, Telnet, or equivalent)."},
-	12: fieldDbEntry{"IPV4_DST_ADDR", 4, fieldToStringIP, "IPv4 destination address."},
-	13: fieldDbEntry{"DST_MASK", 1, fieldToStringUInteger, "The number of contiguous bits in the destination subnet mask (i.e., the mask in slash notation)."},
-	14: fieldDbEntry{"OUTPUT_SNMP", -1, fieldToStringUInteger, "Output interface index. By default N is 2, but higher values can be used."},
-	15: fieldDbEntry{"IPV4_NEXT_HOP", 4, fieldToStringIP, "IPv4 address of the next-hop router."},
-	16: fieldDbEntry{"SRC_AS", -1, fieldToStringUInteger, "Source BGP autonomous system number where N could be 2 or 4. By default N is 2."},
-	17: fieldDbEntry{"DST_AS", -1, fieldToStringUInteger, "Destination BGP autonomous system number where N could be 2 or 4. By default N is 2."},
-	18: fieldDbEntry{"BGP_IPV4_NEXT_HOP", 4, fieldToStringIP, "Next-hop router's IP address in the BGP domain."},
-	19: fieldDbEntry{"MUL_DST_PKTS", -1, fieldToStringUInteger, "IP multicast outgoing packet counter with length N x 8 bits for packets associated with the IP Flow. By default N is 4."},
-	20: fieldDbEntry{"MUL_DST_BYTES", -1, fieldToStringUInteger, "IP multicast outgoing Octet (byte) counter with length N x 8 bits for the number of bytes associated with the IP Flow. By default N is 4."},
-	21: fieldDbEntry{"LAST_SWITCHED", 4, fieldToStringMsecDuration, "sysUptime in msec at which the last packet of this Flow was switched."},
-	22: fieldDbEntry{"FIRST_SWITCHED", 4, fieldToStringMsecDuration, "sysUptime in msec at which the first packet of this Flow was switched."},
-	23: fieldDbEntry{"OUT_BYTES", -1, fieldToStringUInteger, "Outgoing counter with length N x 8 bits for the number of bytes associated with an IP Flow. By default N is 4."},
-	24: fieldDbEntry{"OUT_PKTS", -1, fieldToStringUInteger, "Outgoing counter with length N x 8 bits for the number of packets associated with an IP Flow. By default N is 4."},
-	25: fieldDbEntry{"MIN_PKT_LNGTH", 2, fieldToStringUInteger, "Minimum IP packet length on incoming packets of the flow."},
-	26: fieldDbEntry{"MAX_PKT_LNGTH", 2, fieldToStringUInteger, "Maximum IP packet length on incoming packets of the flow."},
-	27: fieldDbEntry{"IPV6_SRC_ADDR", 16, fieldToStringIP, "IPv6 source address."},
-	28: fieldDbEntry{"IPV6_DST_ADDR", 16, fieldToStringIP, "IPv6 destination address."},
-	29: fieldDbEntry{"IPV6_SRC_MASK", 1, fieldToStringUInteger, "Length of the IPv6 source mask in contiguous bits."},
-	30: fieldDbEntry{"IPV6_DST_MASK", 1, fieldToStringUInteger, "Length of the IPv6 destination mask in contiguous bits."},
-	31: fieldDbEntry{"IPV6_FLOW_LABEL", 3, fieldToStringHex, "IPv6 flow label as per RFC 2460 definition."},
-	32: fieldDbEntry{"ICMP_TYPE", 2, fieldToStringICMPTypeCode, "Internet Control Message Protocol (ICMP) packet type; reported as ICMP Type * 256 + ICMP code."},
-	33: fieldDbEntry{"MUL_IGMP_TYPE", 1, fieldToStringUInteger, "Internet Group Management Protocol (IGMP) packet type."},
-	34: fieldDbEntry{"SAMPLING_INTERVAL", 4, fieldToStringSamplingInterval, "When using sampled NetFlow, the rate at which packets are sampled; for example, a value of 100 indicates that one of every hundred packets is sampled."},
-	35: fieldDbEntry{"SAMPLING_ALGORITHM", 1, fieldToStringSamplingAlgo, "For sampled NetFlow platform-wide: 0x01 deterministic sampling, 0x02 random sampling. Use in connection with SAMPLING_INTERVAL."},
-	36: fieldDbEntry{"FLOW_ACTIVE_TIMEOUT", 2, fieldToStringUInteger, "Timeout value (in seconds) for active flow entries in the NetFlow cache."},
-	37: fieldDbEntry{"FLOW_INACTIVE_TIMEOUT", 2, fieldToStringUInteger, "Timeout value (in seconds) for inactive Flow entries in the NetFlow cache."},
-	38: fieldDbEntry{"ENGINE_TYPE", 1, fieldToStringEngineType, "Type of Flow switching engine (route processor, linecard, etc...)."},
-	39: fieldDbEntry{"ENGINE_ID", 1, fieldToStringUInteger, "ID number of the Flow switching engine."},
-	40: fieldDbEntry{"TOTAL_BYTES_EXP", -1, fieldToStringUInteger, "Counter with length N x 8 bits for the number of bytes exported by the Observation Domain. By default N is 4."},
-	41: fieldDbEntry{"TOTAL_PKTS_EXP", -1, fieldToStringUInteger, "Counter with length N x 8 bits for the number of packets exported by the Observation Domain. By default N is 4."},
-	42: fieldDbEntry{"TOTAL_FLOWS_EXP", -1, fieldToStringUInteger, "Counter with length N x 8 bits for the number of Flows exported by the Observation Domain. By default N is 4."},
-	43: fieldDbEntry{"VENDOR_PROPRIETARY_43", -1, fieldToStringHex, "*Vendor Proprietary*"},
-	44: fieldDbEntry{"IPV4_SRC_PREFIX", 4, fieldToStringIP, "IPv4 source address prefix (specific for Catalyst architecture)."},
-	45: fieldDbEntry{"IPV4_DST_PREFIX", 4, fieldToStringIP, "IPv4 destination address prefix (specific for Catalyst architecture)."},
-	46: fieldDbEntry{"MPLS_TOP_LABEL_TYPE", 1, fieldToStringMPLSTopLabelType, "MPLS Top Label Type: 0x00 UNKNOWN, 0x01 TE-MIDPT, 0x02 ATOM, 0x03 VPN, 0x04 BGP, 0x05 LDP."},
-	47: fieldDbEntry{"MPLS_TOP_LABEL_IP_ADDR", 4, fieldToStringIP, "Forwarding Equivalent Class corresponding to the MPLS Top Label."},
-	48: fieldDbEntry{"FLOW_SAMPLER_ID", -1, fieldToStringUInteger, "Identifier shown in \"show flow-sampler\". By default N is 4."},
-	49: fieldDbEntry{"FLOW_SAMPLER_MODE", 1, fieldToStringSamplingAlgo, "The type of algorithm used for sampling data: 0x02 random sampling. Use in connection with FLOW_SAMPLER_MODE."},
-	50: fieldDbEntry{"FLOW_SAMPLER_RANDOM_INTERVAL", 4, fieldToStringUInteger, "Packet interval at which to sample. Use in connection with FLOW_SAMPLER_MODE."},
-	51: fieldDbEntry{"VENDOR_PROPRIETARY_50", -1, fieldToStringHex, "*Vendor Proprietary*"},
-	52: fieldDbEntry{"MIN_TTL", 1, fieldToStringUInteger, "Minimum TTL on incoming packets of the flow."},
-	53: fieldDbEntry{"MAX_TTL", 1, fieldToStringUInteger, "Maximum TTL on incoming packets of the flow."},
-	54: fieldDbEntry{"IPV4_IDENT", 2, fieldToStringHex, "The IP v4 identification field."},
-	55: fieldDbEntry{"DST_TOS", 1, fieldToStringHex, "Type of Service byte setting when exiting outgoing interface."},
-	56: fieldDbEntry{"IN_SRC_MAC", 6, fieldToStringMAC, "Source MAC Address."},
-	57: fieldDbEntry{"OUT_DST_MAC", 6, fieldToStringMAC, "Destination MAC Address."},
-	58: fieldDbEntry{"SRC_VLAN", 2, fieldToStringUInteger, "Virtual LAN identifier associated with ingress interface."},
-	59: fieldDbEntry{"DST_VLAN", 2, fieldToStringUInteger, "Virtual LAN identifier associated with egress interface."},
-	60: fieldDbEntry{"IP_PROTOCOL_VERSION", 1, fieldToStringUInteger, "Internet Protocol Version. Set to 4 for IPv4, set to 6 for IPv6. If not present in the template, then version 4 is assumed."},
-	61: fieldDbEntry{"DIRECTION", 1, fieldToStringDirection, "Flow direction: 0 - ingress flow, 1 - egress flow."},
-	62: fieldDbEntry{"IPV6_NEXT_HOP", 16, fieldToStringIP, "IPv6 address of the next-hop router."},
-	63: fieldDbEntry{"BGP_IPV6_NEXT_HOP", 16, fieldToStringIP, "Next-hop router in the BGP domain."},
-	64: fieldDbEntry{"IPV6_OPTIONS_HEADERS", 4, fieldToStringHex, "Bit-encoded field identifying IPv6 option headers found in the flow."},
-	65: fieldDbEntry{"VENDOR_PROPRIETARY_65", -1, fieldToStringHex, "*Vendor Proprietary*"},
-	66: fieldDbEntry{"VENDOR_PROPRIETARY_66", -1, fieldToStringHex, "*Vendor Proprietary*"},
-	67: fieldDbEntry{"VENDOR_PROPRIETARY_67", -1, fieldToStringHex, "*Vendor Proprietary*"},
-	68: fieldDbEntry{"VENDOR_PROPRIETARY_68", -1, fieldToStringHex, "*Vendor Proprietary*"},
-	69: fieldDbEntry{"VENDOR_PROPRIETARY_69", -1, fieldToStringHex, "*Vendor Proprietary*"},
-	70: fieldDbEntry{"MPLS_LABEL_1", 3, fieldToStringMPLSLabel, "MPLS label at position 1 in the stack."},
-	71: fieldDbEntry{"MPLS_LABEL_2", 3, fieldToStringMPLSLabel, "MPLS label at position 2 in the stack."},
-	72: fieldDbEntry{"MPLS_LABEL_3", 3, fieldToStringMPLSLabel, "MPLS label at position 3 in the stack."},
-	73: fieldDbEntry{"MPLS_LABEL_4", 3, fieldToStringMPLSLabel, "MPLS label at position 4 in the stack."},
-	74: fieldDbEntry{"MPLS_LABEL_5", 3, fieldToStringMPLSLabel, "MPLS label at position 5 in the stack."},
-	75: fieldDbEntry{"MPLS_LABEL_6", 3, fieldToStringMPLSLabel, "MPLS label at position 6 in the stack."},
-	76: fieldDbEntry{"MPLS_LABEL_7", 3, fieldToStringMPLSLabel, "MPLS label at position 7 in the stack."},
-	77: fieldDbEntry{"MPLS_LABEL_8", 3, fieldToStringMPLSLabel, "MPLS label at position 8 in the stack."},
-	78: fieldDbEntry{"MPLS_LABEL_9", 3, fieldToStringMPLSLabel, "MPLS label at position 9 in the stack."},
-	79: fieldDbEntry{"MPLS_LABEL_10", 3, fieldToStringMPLSLabel, "MPLS label at position 10 in the stack."},
-	80: fieldDbEntry{"IN_DST_MAC", 6, fieldToStringMAC, "Incoming destination MAC address."},
-	81: fieldDbEntry{"OUT_SRC_MAC", 6, fieldToStringMAC, "Outgoing source MAC address."},
-	82: fieldDbEntry{"IF_NAME", -1, fieldToStringASCII, "Shortened interface name i.e.: \"FE1/0\"."},
-	83: fieldDbEntry{"IF_DESC", -1, fieldToStringASCII, "Full interface name i.e.: \"FastEthernet 1/0\"."},
-	84: fieldDbEntry{"SAMPLER_NAME", -1, fieldToStringASCII, "Name of the flow sampler."},
-	85: fieldDbEntry{"IN_PERMANENT_BYTES", -1, fieldToStringUInteger, "Running byte counter for a permanent flow. By default N is 4."},
-	86: fieldDbEntry{"IN_PERMANENT_PKTS", -1, fieldToStringUInteger, "Running packet counter for a permanent flow. By default N is 4."},
-	87: fieldDbEntry{"VENDOR_PROPRIETARY_87", -1, fieldToStringHex, "*Vendor Proprietary*"},
-	88: fieldDbEntry{"FRAGMENT_OFFSET", 2, fieldToStringUInteger, "The fragment-offset value from fragmented IP packets."},
-	89: fieldDbEntry{"FORWARDING_STATUS", 1, fieldToStringHex, "Forwarding status is encoded on 1 byte with the 2 left bits giving the status and the 6 remaining bits giving the reason code."},
-	90: fieldDbEntry{"MPLS_PAL_RD", 8, fieldToStringHex, "MPLS PAL Route Distinguisher."},
-	91: fieldDbEntry{"MPLS_PREFIX_LEN", 1, fieldToStringUInteger, "Number of consecutive bits in the MPLS prefix length."},
-	92: fieldDbEntry{"SRC_TRAFFIC_INDEX", 4, fieldToStringUInteger, "BGP Policy Accounting Source Traffic Index."},
-	93: fieldDbEntry{"DST_TRAFFIC_INDEX", 4, fieldToStringUInteger, "BGP Policy Accounting Destination Traffic Index."},
-	94: fieldDbEntry{"APPLICATION_DESCRIPTION", -1, fieldToStringASCII, "Application description."},
-	95: fieldDbEntry{"APPLICATION_TAG", -1, fieldToStringHex, "8 bits of engine ID, followed by n bits of classification."},
-	96: fieldDbEntry{"APPLICATION_NAME", -1, fieldToStringASCII, "Name associated with a classification."},
+	1:  fieldDbEntry{"IN_BYTES", -1, fieldToStringUInteger, fieldToValueUInteger, "Incoming counter with length N x 8 bits for the number of bytes associated with an IP Flow. By default N is 4."},
+	2:  fieldDbEntry{"IN_PKTS", -1, fieldToStringUInteger, fieldToValueUInteger, "Incoming counter with length N x 8 bits for the number of packes associated with an IP Flow. By default N is 4."},
+	3:  fieldDbEntry{"FLOWS", -1, fieldToStringUInteger, fieldToValueUInteger, "Number of Flows that were aggregated; by default N is 4."},
+	4:  fieldDbEntry{"PROTOCOL", 1, fieldToStringHex, fieldToValueUInteger, "IP protocol byte."},
+	5:  fieldDbEntry{"SRC_TOS", 1, fieldToStringHex, fieldToValueUInteger, "Type of service byte setting when entering the incoming interface."},
+	6:  fieldDbEntry{"TCP_FLAGS", 1, fieldToStringTCPFlags, fieldToValueTCPFlags, "TCP flags; cumulative of all the TCP flags seen in this Flow."},
+	7:  fieldDbEntry{"L4_SRC_PORT", 2, fieldToStringUInteger, fieldToValueUInteger, "TCP/UDP source port number (for example, FTP, Telnet, or equivalent)."},
+	8:  fieldDbEntry{"IPV4_SRC_ADDR", 4, fieldToStringIP, fieldToValueIP, "IPv4 source address."},
+	9:  fieldDbEntry{"SRC_MASK", 1, fieldToStringUInteger, fieldToValueUInteger, "The number of contiguous bits in the source subnet mask (i.e., the mask in slash notation)."},
+	10: fieldDbEntry{"INPUT_SNMP", -1, fieldToStringUInteger, fieldToValueUInteger, "Input interface index. By default N is 2, but higher values can be used."},
+	11: fieldDbEntry{"L4_DST_PORT", 2, fieldToStringUInteger, fieldToValueUInteger, "TCP/UDP destination port number (for example, FTP, Telnet, or equivalent)."},
+	12: fieldDbEntry{"IPV4_DST_ADDR", 4, fieldToStringIP, fieldToValueIP, "IPv4 destination address."},
+	13: fieldDbEntry{"DST_MASK", 1, fieldToStringUInteger, fieldToValueUInteger, "The number of contiguous bits in the destination subnet mask (i.e., the mask in slash notation)."},
+	14: fieldDbEntry{"OUTPUT_SNMP", -1, fieldToStringUInteger, fieldToValueUInteger, "Output interface index. By default N is 2, but higher values can be used."},
+	15: fieldDbEntry{"IPV4_NEXT_HOP", 4, fieldToStringIP, fieldToValueIP, "IPv4 address of the next-hop router."},
+	16: fieldDbEntry{"SRC_AS", -1, fieldToStringUInteger, fieldToValueUInteger, "Source BGP autonomous system number where N could be 2 or 4. By default N is 2."},
+	17: fieldDbEntry{"DST_AS", -1, fieldToStringUInteger, fieldToValueUInteger, "Destination BGP autonomous system number where N could be 2 or 4. By default N is 2."},
+	18: fieldDbEntry{"BGP_IPV4_NEXT_HOP", 4, fieldToStringIP, fieldToValueIP, "Next-hop router's IP address in the BGP domain."},
+	19: fieldDbEntry{"MUL_DST_PKTS", -1, fieldToStringUInteger, fieldToValueUInteger, "IP multicast outgoing packet counter with length N x 8 bits for packets associated with the IP Flow. By default N is 4."},
+	20: fieldDbEntry{"MUL_DST_BYTES", -1, fieldToStringUInteger, fieldToValueUInteger, "IP multicast outgoing Octet (byte) counter with length N x 8 bits for the number of bytes associated with the IP Flow. By default N is 4."},
+	21: fieldDbEntry{"LAST_SWITCHED", 4, fieldToStringMsecDuration, fieldToValueMsecDuration, "sysUptime in msec at which the last packet of this Flow was switched."},
+	22: fieldDbEntry{"FIRST_SWITCHED", 4, fieldToStringMsecDuration, fieldToValueMsecDuration, "sysUptime in msec at which the first packet of this Flow was switched."},
+	23: fieldDbEntry{"OUT_BYTES", -1, fieldToStringUInteger, fieldToValueUInteger, "Outgoing counter with length N x 8 bits for the number of bytes associated with an IP Flow. By default N is 4."},
+	24: fieldDbEntry{"OUT_PKTS", -1, fieldToStringUInteger, fieldToValueUInteger, "Outgoing counter with length N x 8 bits for the number of packets associated with an IP Flow. By default N is 4."},
+	25: fieldDbEntry{"MIN_PKT_LNGTH", 2, fieldToStringUInteger, fieldToValueUInteger, "Minimum IP packet length on incoming packets of the flow."},
+	26: fieldDbEntry{"MAX_PKT_LNGTH", 2, fieldToStringUInteger, fieldToValueUInteger, "Maximum IP packet length on incoming packets of the flow."},
+	27: fieldDbEntry{"IPV6_SRC_ADDR", 16, fieldToStringIP, fieldToValueIP, "IPv6 source address."},
+	28: fieldDbEntry{"IPV6_DST_ADDR", 16, fieldToStringIP, fieldToValueIP, "IPv6 destination address."},
+	29: fieldDbEntry{"IPV6_SRC_MASK", 1, fieldToStringUInteger, fieldToValueUInteger, "Length of the IPv6 source mask in contiguous bits."},
+	30: fieldDbEntry{"IPV6_DST_MASK", 1, fieldToStringUInteger, fieldToValueUInteger, "Length of the IPv6 destination mask in contiguous bits."},
+	31: fieldDbEntry{"IPV6_FLOW_LABEL", 3, fieldToStringHex, fieldToValueUInteger, "IPv6 flow label as per RFC 2460 definition."},
+	32: fieldDbEntry{"ICMP_TYPE", 2, fieldToStringICMPTypeCode, fieldToValueICMPTypeCode, "Internet Control Message Protocol (ICMP) packet type; reported as ICMP Type * 256 + ICMP code."},
+	33: fieldDbEntry{"MUL_IGMP_TYPE", 1, fieldToStringUInteger, fieldToValueUInteger, "Internet Group Management Protocol (IGMP) packet type."},
+	34: fieldDbEntry{"SAMPLING_INTERVAL", 4, fieldToStringSamplingInterval, fieldToValueUInteger, "When using sampled NetFlow, the rate at which packets are sampled; for example, a value of 100 indicates that one of every hundred packets is sampled."},
+	35: fieldDbEntry{"SAMPLING_ALGORITHM", 1, fieldToStringSamplingAlgo, fieldToValueUInteger, "For sampled NetFlow platform-wide: 0x01 deterministic sampling, 0x02 random sampling. Use in connection with SAMPLING_INTERVAL."},
+	36: fieldDbEntry{"FLOW_ACTIVE_TIMEOUT", 2, fieldToStringUInteger, fieldToValueUInteger, "Timeout value (in seconds) for active flow entries in the NetFlow cache."},
+	37: fieldDbEntry{"FLOW_INACTIVE_TIMEOUT", 2, fieldToStringUInteger, fieldToValueUInteger, "Timeout value (in seconds) for inactive Flow entries in the NetFlow cache."},
+	38: fieldDbEntry{"ENGINE_TYPE", 1, fieldToStringEngineType, fieldToValueUInteger, "Type of Flow switching engine (route processor, linecard, etc...)."},
+	39: fieldDbEntry{"ENGINE_ID", 1, fieldToStringUInteger, fieldToValueUInteger, "ID number of the Flow switching engine."},
+	40: fieldDbEntry{"TOTAL_BYTES_EXP", -1, fieldToStringUInteger, fieldToValueUInteger, "Counter with length N x 8 bits for the number of bytes exported by the Observation Domain. By default N is 4."},
+	41: fieldDbEntry{"TOTAL_PKTS_EXP", -1, fieldToStringUInteger, fieldToValueUInteger, "Counter with length N x 8 bits for the number of packets exported by the Observation Domain. By default N is 4."},
+	42: fieldDbEntry{"TOTAL_FLOWS_EXP", -1, fieldToStringUInteger, fieldToValueUInteger, "Counter with length N x 8 bits for the number of Flows exported by the Observation Domain. By default N is 4."},
+	43: fieldDbEntry{"VENDOR_PROPRIETARY_43", -1, fieldToStringHex, fieldToValueBytes, "*Vendor Proprietary*"},
+	44: fieldDbEntry{"IPV4_SRC_PREFIX", 4, fieldToStringIP, fieldToValueIP, "IPv4 source address prefix (specific for Catalyst architecture)."},
+	45: fieldDbEntry{"IPV4_DST_PREFIX", 4, fieldToStringIP, fieldToValueIP, "IPv4 destination address prefix (specific for Catalyst architecture)."},
+	46: fieldDbEntry{"MPLS_TOP_LABEL_TYPE", 1, fieldToStringMPLSTopLabelType, fieldToValueUInteger, "MPLS Top Label Type: 0x00 UNKNOWN, 0x01 TE-MIDPT, 0x02 ATOM, 0x03 VPN, 0x04 BGP, 0x05 LDP."},
+	47: fieldDbEntry{"MPLS_TOP_LABEL_IP_ADDR", 4, fieldToStringIP, fieldToValueIP, "Forwarding Equivalent Class corresponding to the MPLS Top Label."},
+	48: fieldDbEntry{"FLOW_SAMPLER_ID", -1, fieldToStringUInteger, fieldToValueUInteger, "Identifier shown in \"show flow-sampler\". By default N is 4."},
+	49: fieldDbEntry{"FLOW_SAMPLER_MODE", 1, fieldToStringSamplingAlgo, fieldToValueUInteger, "The type of algorithm used for sampling data: 0x02 random sampling. Use in connection with FLOW_SAMPLER_MODE."},
+	50: fieldDbEntry{"FLOW_SAMPLER_RANDOM_INTERVAL", 4, fieldToStringUInteger, fieldToValueUInteger, "Packet interval at which to sample. Use in connection with FLOW_SAMPLER_MODE."},
+	51: fieldDbEntry{"VENDOR_PROPRIETARY_50", -1, fieldToStringHex, fieldToValueBytes, "*Vendor Proprietary*"},
+	52: fieldDbEntry{"MIN_TTL", 1, fieldToStringUInteger, fieldToValueUInteger, "Minimum TTL on incoming packets of the flow."},
+	53: fieldDbEntry{"MAX_TTL", 1, fieldToStringUInteger, fieldToValueUInteger, "Maximum TTL on incoming packets of the flow."},
+	54: fieldDbEntry{"IPV4_IDENT", 2, fieldToStringHex, fieldToValueUInteger, "The IP v4 identification field."},
+	55: fieldDbEntry{"DST_TOS", 1, fieldToStringHex, fieldToValueUInteger, "Type of Service byte setting when exiting outgoing interface."},
+	56: fieldDbEntry{"IN_SRC_MAC", 6, fieldToStringMAC, fieldToValueMAC, "Source MAC Address."},
+	57: fieldDbEntry{"OUT_DST_MAC", 6, fieldToStringMAC, fieldToValueMAC, "Destination MAC Address."},
+	58: fieldDbEntry{"SRC_VLAN", 2, fieldToStringUInteger, fieldToValueUInteger, "Virtual LAN identifier associated with ingress interface."},
+	59: fieldDbEntry{"DST_VLAN", 2, fieldToStringUInteger, fieldToValueUInteger, "Virtual LAN identifier associated with egress interface."},
+	60: fieldDbEntry{"IP_PROTOCOL_VERSION", 1, fieldToStringUInteger, fieldToValueUInteger, "Internet Protocol Version. Set to 4 for IPv4, set to 6 for IPv6. If not present in the template, then version 4 is assumed."},
+	61: fieldDbEntry{"DIRECTION", 1, fieldToStringDirection, fieldToValueUInteger, "Flow direction: 0 - ingress flow, 1 - egress flow."},
+	62: fieldDbEntry{"IPV6_NEXT_HOP", 16, fieldToStringIP, fieldToValueIP, "IPv6 address of the next-hop router."},
+	63: fieldDbEntry{"BGP_IPV6_NEXT_HOP", 16, fieldToStringIP, fieldToValueIP, "Next-hop router in the BGP domain."},
+	64: fieldDbEntry{"IPV6_OPTIONS_HEADERS", 4, fieldToStringHex, fieldToValueUInteger, "Bit-encoded field identifying IPv6 option headers found in the flow."},
+	65: fieldDbEntry{"VENDOR_PROPRIETARY_65", -1, fieldToStringHex, fieldToValueBytes, "*Vendor Proprietary*"},
+	66: fieldDbEntry{"VENDOR_PROPRIETARY_66", -1, fieldToStringHex, fieldToValueBytes, "*Vendor Proprietary*"},
+	67: fieldDbEntry{"VENDOR_PROPRIETARY_67", -1, fieldToStringHex, fieldToValueBytes, "*Vendor Proprietary*"},
+	68: fieldDbEntry{"VENDOR_PROPRIETARY_68", -1, fieldToStringHex, fieldToValueBytes, "*Vendor Proprietary*"},
+	69: fieldDbEntry{"VENDOR_PROPRIETARY_69", -1, fieldToStringHex, fieldToValueBytes, "*Vendor Proprietary*"},
+	70: fieldDbEntry{"MPLS_LABEL_1", 3, fieldToStringMPLSLabel, fieldToValueMPLSLabel, "MPLS label at position 1 in the stack."},
+	71: fieldDbEntry{"MPLS_LABEL_2", 3, fieldToStringMPLSLabel, fieldToValueMPLSLabel, "MPLS label at position 2 in the stack."},
+	72: fieldDbEntry{"MPLS_LABEL_3", 3, fieldToStringMPLSLabel, fieldToValueMPLSLabel, "MPLS label at position 3 in the stack."},
+	73: fieldDbEntry{"MPLS_LABEL_4", 3, fieldToStringMPLSLabel, fieldToValueMPLSLabel, "MPLS label at position 4 in the stack."},
+	74: fieldDbEntry{"MPLS_LABEL_5", 3, fieldToStringMPLSLabel, fieldToValueMPLSLabel, "MPLS label at position 5 in the stack."},
+	75: fieldDbEntry{"MPLS_LABEL_6", 3, fieldToStringMPLSLabel, fieldToValueMPLSLabel, "MPLS label at position 6 in the stack."},
+	76: fieldDbEntry{"MPLS_LABEL_7", 3, fieldToStringMPLSLabel, fieldToValueMPLSLabel, "MPLS label at position 7 in the stack."},
+	77: fieldDbEntry{"MPLS_LABEL_8", 3, fieldToStringMPLSLabel, fieldToValueMPLSLabel, "MPLS label at position 8 in the stack."},
+	78: fieldDbEntry{"MPLS_LABEL_9", 3, fieldToStringMPLSLabel, fieldToValueMPLSLabel, "MPLS label at position 9 in the stack."},
+	79: fieldDbEntry{"MPLS_LABEL_10", 3, fieldToStringMPLSLabel, fieldToValueMPLSLabel, "MPLS label at position 10 in the stack."},
+	80: fieldDbEntry{"IN_DST_MAC", 6, fieldToStringMAC, fieldToValueMAC, "Incoming destination MAC address."},
+	81: fieldDbEntry{"OUT_SRC_MAC", 6, fieldToStringMAC, fieldToValueMAC, "Outgoing source MAC address."},
+	82: fieldDbEntry{"IF_NAME", -1, fieldToStringASCII, fieldToValueASCII, "Shortened interface name i.e.: \"FE1/0\"."},
+	83: fieldDbEntry{"IF_DESC", -1, fieldToStringASCII, fieldToValueASCII, "Full interface name i.e.: \"FastEthernet 1/0\"."},
+	84: fieldDbEntry{"SAMPLER_NAME", -1, fieldToStringASCII, fieldToValueASCII, "Name of the flow sampler."},
+	85: fieldDbEntry{"IN_PERMANENT_BYTES", -1, fieldToStringUInteger, fieldToValueUInteger, "Running byte counter for a permanent flow. By default N is 4."},
+	86: fieldDbEntry{"IN_PERMANENT_PKTS", -1, fieldToStringUInteger, fieldToValueUInteger, "Running packet counter for a permanent flow. By default N is 4."},
+	87: fieldDbEntry{"VENDOR_PROPRIETARY_87", -1, fieldToStringHex, fieldToValueBytes, "*Vendor Proprietary*"},
+	88: fieldDbEntry{"FRAGMENT_OFFSET", 2, fieldToStringUInteger, fieldToValueUInteger, "The fragment-offset value from fragmented IP packets."},
+	89: fieldDbEntry{"FORWARDING_STATUS", 1, fieldToStringHex, fieldToValueUInteger, "Forwarding status is encoded on 1 byte with the 2 left bits giving the status and the 6 remaining bits giving the reason code."},
+	90: fieldDbEntry{"MPLS_PAL_RD", 8, fieldToStringHex, fieldToValueBytes, "MPLS PAL Route Distinguisher."},
+	91: fieldDbEntry{"MPLS_PREFIX_LEN", 1, fieldToStringUInteger, fieldToValueUInteger, "Number of consecutive bits in the MPLS prefix length."},
+	92: fieldDbEntry{"SRC_TRAFFIC_INDEX", 4, fieldToStringUInteger, fieldToValueUInteger, "BGP Policy Accounting Source Traffic Index."},
+	93: fieldDbEntry{"DST_TRAFFIC_INDEX", 4, fieldToStringUInteger, fieldToValueUInteger, "BGP Policy Accounting Destination Traffic Index."},
+	94: fieldDbEntry{"APPLICATION_DESCRIPTION", -1, fieldToStringASCII, fieldToValueASCII, "Application description."},
+	95: fieldDbEntry{"APPLICATION_TAG", -1, fieldToStringApplicationTag, fieldToValueApplicationTag, "8 bits of engine ID, followed by n bits of classification."},
+	96: fieldDbEntry{"APPLICATION_NAME", -1, fieldToStringASCII, fieldToValueASCII, "Name associated with a classification."},
+
+	128: fieldDbEntry{"BGP_NEXT_ADJACENT_ASN", 4, fieldToStringUInteger, fieldToValueUInteger, "The autonomous system (AS) number of the first AS in the AS path to the destination IP address."},
+	129: fieldDbEntry{"BGP_PREV_ADJACENT_ASN", 4, fieldToStringUInteger, fieldToValueUInteger, "The autonomous system (AS) number of the last AS in the AS path from the source IP address."},
+	130: fieldDbEntry{"EXPORTER_IPV4_ADDRESS", 4, fieldToStringIP, fieldToValueIP, "The IPv4 address used by the Exporting Process."},
+	131: fieldDbEntry{"EXPORTER_IPV6_ADDRESS", 16, fieldToStringIP, fieldToValueIP, "The IPv6 address used by the Exporting Process."},
+	132: fieldDbEntry{"DROPPED_BYTES", -1, fieldToStringUInteger, fieldToValueUInteger, "The number of octets since the previous report in packets of this Flow dropped by packet treatment. By default N is 8."},
+	133: fieldDbEntry{"DROPPED_PKTS", -1, fieldToStringUInteger, fieldToValueUInteger, "The number of packets since the previous report of this Flow dropped by packet treatment. By default N is 8."},
+	136: fieldDbEntry{"FLOW_END_REASON", 1, fieldToStringFlowEndReason, fieldToValueUInteger, "The reason for Flow termination: 0x01 idle timeout, 0x02 active timeout, 0x03 end of Flow detected, 0x04 forced end, 0x05 lack of resources."},
+	148: fieldDbEntry{"CONN_ID", -1, fieldToStringUInteger, fieldToValueUInteger, "An identifier of a Flow that is unique within an Observation Domain, the connection ID on Cisco ASA. By default N is 4."},
+	150: fieldDbEntry{"FLOW_START_SECONDS", 4, fieldToStringDateTimeSeconds, fieldToValueDateTimeSeconds, "The absolute timestamp of the first packet of this Flow, in seconds since 0000 UTC 1970."},
+	151: fieldDbEntry{"FLOW_END_SECONDS", 4, fieldToStringDateTimeSeconds, fieldToValueDateTimeSeconds, "The absolute timestamp of the last packet of this Flow, in seconds since 0000 UTC 1970."},
+	152: fieldDbEntry{"FLOW_START_MILLISECONDS", 8, fieldToStringDateTimeMilliseconds, fieldToValueDateTimeMilliseconds, "The absolute timestamp of the first packet of this Flow, in milliseconds since 0000 UTC 1970."},
+	153: fieldDbEntry{"FLOW_END_MILLISECONDS", 8, fieldToStringDateTimeMilliseconds, fieldToValueDateTimeMilliseconds, "The absolute timestamp of the last packet of this Flow, in milliseconds since 0000 UTC 1970."},
+	160: fieldDbEntry{"SYSTEM_INIT_TIME_MILLISECONDS", 8, fieldToStringDateTimeMilliseconds, fieldToValueDateTimeMilliseconds, "The absolute timestamp of the last (re-)initialization of the exporter, in milliseconds since 0000 UTC 1970."},
+	176: fieldDbEntry{"ICMP_IPV4_TYPE", 1, fieldToStringUInteger, fieldToValueUInteger, "Type of the IPv4 ICMP message."},
+	177: fieldDbEntry{"ICMP_IPV4_CODE", 1, fieldToStringUInteger, fieldToValueUInteger, "Code of the IPv4 ICMP message."},
+	178: fieldDbEntry{"ICMP_IPV6_TYPE", 1, fieldToStringUInteger, fieldToValueUInteger, "Type of the IPv6 ICMP message."},
+	179: fieldDbEntry{"ICMP_IPV6_CODE", 1, fieldToStringUInteger, fieldToValueUInteger, "Code of the IPv6 ICMP message."},
+	225: fieldDbEntry{"POST_NAT_SRC_IPV4_ADDR", 4, fieldToStringIP, fieldToValueIP, "IPv4 source address after Network Address Translation."},
+	226: fieldDbEntry{"POST_NAT_DST_IPV4_ADDR", 4, fieldToStringIP, fieldToValueIP, "IPv4 destination address after Network Address Translation."},
+	227: fieldDbEntry{"POST_NAPT_SRC_TRANSPORT_PORT", 2, fieldToStringUInteger, fieldToValueUInteger, "TCP/UDP source port number after Network Address and Port Translation."},
+	228: fieldDbEntry{"POST_NAPT_DST_TRANSPORT_PORT", 2, fieldToStringUInteger, fieldToValueUInteger, "TCP/UDP destination port number after Network Address and Port Translation."},
+	230: fieldDbEntry{"NAT_EVENT", 1, fieldToStringNATEvent, fieldToValueUInteger, "Type of the NAT event, for example 0x01 NAT translation create, 0x02 NAT translation delete."},
+	231: fieldDbEntry{"INITIATOR_OCTETS", -1, fieldToStringUInteger, fieldToValueUInteger, "The total number of layer 4 payload bytes in a flow from the initiator. By default N is 8, Cisco ASA uses 4."},
+	232: fieldDbEntry{"RESPONDER_OCTETS", -1, fieldToStringUInteger, fieldToValueUInteger, "The total number of layer 4 payload bytes in a flow from the responder. By default N is 8, Cisco ASA uses 4."},
+	233: fieldDbEntry{"FW_EVENT", 1, fieldToStringFirewallEvent, fieldToValueUInteger, "Firewall event: 0x00 ignore, 0x01 flow created, 0x02 flow deleted, 0x03 flow denied, 0x04 flow alert, 0x05 flow update."},
+	234: fieldDbEntry{"INGRESS_VRFID", 4, fieldToStringUInteger, fieldToValueUInteger, "Unique identifier of the VRF name where the packets of this flow are being received."},
+	235: fieldDbEntry{"EGRESS_VRFID", 4, fieldToStringUInteger, fieldToValueUInteger, "Unique identifier of the VRF name where the packets of this flow are being sent."},
+	236: fieldDbEntry{"VRFNAME", -1, fieldToStringASCII, fieldToValueASCII, "The name of a VPN Routing and Forwarding table (VRF)."},
+	298: fieldDbEntry{"INITIATOR_PKTS", -1, fieldToStringUInteger, fieldToValueUInteger, "The total number of layer 4 packets in a flow from the initiator. By default N is 8."},
+	299: fieldDbEntry{"RESPONDER_PKTS", -1, fieldToStringUInteger, fieldToValueUInteger, "The total number of layer 4 packets in a flow from the responder. By default N is 8."},
+	323: fieldDbEntry{"OBSERVATION_TIME_MILLISECONDS", 8, fieldToStringDateTimeMilliseconds, fieldToValueDateTimeMilliseconds, "The absolute timestamp of an observation, in milliseconds since 0000 UTC 1970."},
+	361: fieldDbEntry{"PORT_RANGE_START", 2, fieldToStringUInteger, fieldToValueUInteger, "The port number identifying the start of a range of ports allocated by NAT."},
+	362: fieldDbEntry{"PORT_RANGE_END", 2, fieldToStringUInteger, fieldToValueUInteger, "The port number identifying the end of a range of ports allocated by NAT."},
+	363: fieldDbEntry{"PORT_RANGE_STEP_SIZE", 2, fieldToStringUInteger, fieldToValueUInteger, "The step size in a port range allocated by NAT."},
+	364: fieldDbEntry{"PORT_RANGE_NUM_PORTS", 2, fieldToStringUInteger, fieldToValueUInteger, "The number of ports in a port range allocated by NAT."},
+
+	// Cisco ASA NetFlow Security Event Logging (NSEL) extensions.
+	33000: fieldDbEntry{"INGRESS_ACL_ID", 12, fieldToStringHex, fieldToValueBytes, "Input ACL that permitted or denied the flow: ACL ID, ACE ID and extended ACE ID, 4 bytes each."},
+	33001: fieldDbEntry{"EGRESS_ACL_ID", 12, fieldToStringHex, fieldToValueBytes, "Output ACL that permitted or denied the flow: ACL ID, ACE ID and extended ACE ID, 4 bytes each."},
+	33002: fieldDbEntry{"FW_EXT_EVENT", 2, fieldToStringUInteger, fieldToValueUInteger, "Extended firewall event code giving more detail about FW_EVENT, for example 1001 denied by ingress ACL."},
+	40000: fieldDbEntry{"USERNAME", -1, fieldToStringASCII, fieldToValueASCII, "AAA user name associated with the connection, 20 or 65 bytes."},
+	40001: fieldDbEntry{"XLATE_SRC_ADDR_IPV4", 4, fieldToStringIP, fieldToValueIP, "Translated IPv4 source address (deprecated, use POST_NAT_SRC_IPV4_ADDR)."},
+	40002: fieldDbEntry{"XLATE_DST_ADDR_IPV4", 4, fieldToStringIP, fieldToValueIP, "Translated IPv4 destination address (deprecated, use POST_NAT_DST_IPV4_ADDR)."},
+	40003: fieldDbEntry{"XLATE_SRC_PORT", 2, fieldToStringUInteger, fieldToValueUInteger, "Translated source port (deprecated, use POST_NAPT_SRC_TRANSPORT_PORT)."},
+	40004: fieldDbEntry{"XLATE_DST_PORT", 2, fieldToStringUInteger, fieldToValueUInteger, "Translated destination port (deprecated, use POST_NAPT_DST_TRANSPORT_PORT)."},
+	40005: fieldDbEntry{"FW_EVENT_LEGACY", 1, fieldToStringFirewallEvent, fieldToValueUInteger, "Firewall event (deprecated, use FW_EVENT)."},
 }
 
 func fieldToUInteger(data []byte) (num uint64) {
@@ -146,52 +193,20 @@ func fieldToStringMAC(data []byte) string {
 	return net.HardwareAddr(data).String()
 }
 
-func fieldToStringTCPFlags(data []byte) (flags string) {
-	if data[0]&0x80 > 0 {
-		flags += "C"
-	} else {
-		flags += " "
-	}
-	if data[0]&0x40 > 0 {
-		flags += "E"
-	} else {
-		flags += " "
-	}
-	if data[0]&0x20 > 0 {
-		flags += "U"
-	} else {
-		flags += " "
-	}
-	if data[0]&0x10 > 0 {
-		flags += "A"
-	} else {
-		flags += " "
-	}
-	if data[0]&0x08 > 0 {
-		flags += "P"
-	} else {
-		flags += " "
-	}
-	if data[0]&0x04 > 0 {
-		flags += "R"
-	} else {
-		flags += " "
+func fieldToStringTCPFlags(data []byte) string {
+	v, err := fieldToValueTCPFlags(data)
+	if err != nil {
+		return "n/a"
 	}
-	if data[0]&0x02 > 0 {
-		flags += "S"
-	} else {
-		flags += " "
-	}
-	if data[0]&0x01 > 0 {
-		flags += "F"
-	} else {
-		flags += " "
-	}
-	return
+	return v.(TCPFlags).String()
 }
 
 func fieldToStringICMPTypeCode(data []byte) string {
-	return fmt.Sprintf("%d/%d", data[0], data[1])
+	v, err := fieldToValueICMPTypeCode(data)
+	if err != nil {
+		return "n/a"
+	}
+	return v.(ICMPTypeCode).String()
 }
 
 func fieldToStringMsecDuration(data []byte) string {
@@ -199,11 +214,30 @@ func fieldToStringMsecDuration(data []byte) string {
 	return duration.String()
 }
 
+func fieldToStringDateTimeSeconds(data []byte) string {
+	v, err := fieldToValueDateTimeSeconds(data)
+	if err != nil {
+		return "n/a"
+	}
+	return v.(time.Time).Format(time.RFC3339)
+}
+
+func fieldToStringDateTimeMilliseconds(data []byte) string {
+	v, err := fieldToValueDateTimeMilliseconds(data)
+	if err != nil {
+		return "n/a"
+	}
+	return v.(time.Time).Format(time.RFC3339Nano)
+}
+
 func fieldToStringSamplingInterval(data []byte) string {
 	return "1 out of " + fieldToStringUInteger(data)
 }
 
 func fieldToStringSamplingAlgo(data []byte) string {
+	if len(data) != 1 {
+		return "n/a"
+	}
 	switch data[0] {
 	case 0x01:
 		return "Deterministic"
@@ -215,6 +249,9 @@ func fieldToStringSamplingAlgo(data []byte) string {
 }
 
 func fieldToStringEngineType(data []byte) string {
+	if len(data) != 1 {
+		return "n/a"
+	}
 	switch data[0] {
 	case 0x00:
 		return "Routing Processor"
@@ -226,6 +263,9 @@ func fieldToStringEngineType(data []byte) string {
 }
 
 func fieldToStringMPLSTopLabelType(data []byte) string {
+	if len(data) != 1 {
+		return "n/a"
+	}
 	switch data[0] {
 	case 0x01:
 		return "TE-MIDPT"
@@ -243,6 +283,9 @@ func fieldToStringMPLSTopLabelType(data []byte) string {
 }
 
 func fieldToStringDirection(data []byte) string {
+	if len(data) != 1 {
+		return "n/a"
+	}
 	switch data[0] {
 	case 0:
 		return "Ingress"
@@ -253,14 +296,106 @@ func fieldToStringDirection(data []byte) string {
 	}
 }
 
-func fieldToStringMPLSLabel(bytes []uint8) string {
-	var label int
-	var exp int
-	var bottom int
+func fieldToStringFlowEndReason(data []byte) string {
+	if len(data) != 1 {
+		return "n/a"
+	}
+	switch data[0] {
+	case 0x01:
+		return "Idle timeout"
+	case 0x02:
+		return "Active timeout"
+	case 0x03:
+		return "End of Flow"
+	case 0x04:
+		return "Forced end"
+	case 0x05:
+		return "Lack of resources"
+	default:
+		return "Unknown"
+	}
+}
 
-	label = (int(bytes[0]) << 12) | (int(bytes[1]) << 4) | ((int(bytes[2]) & 0xf0) >> 4)
-	exp = int(bytes[2]) & 0x0e
-	bottom = int(bytes[0]) & 0x01
+func fieldToStringNATEvent(data []byte) string {
+	if len(data) != 1 {
+		return "n/a"
+	}
+	switch data[0] {
+	case 1:
+		return "NAT translation create"
+	case 2:
+		return "NAT translation delete"
+	case 3:
+		return "NAT addresses exhausted"
+	case 4:
+		return "NAT44 session create"
+	case 5:
+		return "NAT44 session delete"
+	case 6:
+		return "NAT64 session create"
+	case 7:
+		return "NAT64 session delete"
+	case 8:
+		return "NAT44 BIB create"
+	case 9:
+		return "NAT44 BIB delete"
+	case 10:
+		return "NAT64 BIB create"
+	case 11:
+		return "NAT64 BIB delete"
+	case 12:
+		return "NAT ports exhausted"
+	case 13:
+		return "Quota exceeded"
+	case 14:
+		return "Address binding create"
+	case 15:
+		return "Address binding delete"
+	case 16:
+		return "Port block allocation"
+	case 17:
+		return "Port block de-allocation"
+	case 18:
+		return "Threshold reached"
+	default:
+		return "Unknown"
+	}
+}
 
-	return fmt.Sprintf("%d/%d/%d", label, exp, bottom)
+func fieldToStringFirewallEvent(data []byte) string {
+	if len(data) != 1 {
+		return "n/a"
+	}
+	switch data[0] {
+	case 0:
+		return "Ignore"
+	case 1:
+		return "Flow created"
+	case 2:
+		return "Flow deleted"
+	case 3:
+		return "Flow denied"
+	case 4:
+		return "Flow alert"
+	case 5:
+		return "Flow update"
+	default:
+		return "Unknown"
+	}
+}
+
+func fieldToStringApplicationTag(data []byte) string {
+	t, err := ParseApplicationTag(data)
+	if err != nil {
+		return fieldToStringHex(data)
+	}
+	return t.String()
+}
+
+func fieldToStringMPLSLabel(data []byte) string {
+	v, err := fieldToValueMPLSLabel(data)
+	if err != nil {
+		return "n/a"
+	}
+	return v.(MPLSLabel).String()
 }
@@ -0,0 +1,112 @@
+package nf9packet
+
+// FlowSetKind identifies the type of a FlowSet.
+type FlowSetKind int
+
+// FlowSet kinds.
+const (
+	KindDataFlowSet FlowSetKind = iota
+	KindTemplateFlowSet
+	KindOptionsTemplateFlowSet
+)
+
+// String returns FlowSet type name.
+func (k FlowSetKind) String() string {
+	switch k {
+	case KindDataFlowSet:
+		return "Data FlowSet"
+	case KindTemplateFlowSet:
+		return "Template FlowSet"
+	case KindOptionsTemplateFlowSet:
+		return "Options Template FlowSet"
+	default:
+		return "Unknown FlowSet"
+	}
+}
+
+// FlowSet is a single FlowSet of a packet. It is implemented by *DataFlowSet,
+// *TemplateFlowSet and *OptionsTemplateFlowSet only, so a type switch over
+// these three types is exhaustive.
+type FlowSet interface {
+	// Header returns FlowSet ID and length.
+	Header() FlowSetHeader
+
+	// Kind returns the type of the FlowSet.
+	Kind() FlowSetKind
+
+	accept(v Visitor) error
+}
+
+// Header returns FlowSet ID and length.
+func (set *DataFlowSet) Header() FlowSetHeader { return set.FlowSetHeader }
+
+// Header returns FlowSet ID and length.
+func (set *TemplateFlowSet) Header() FlowSetHeader { return set.FlowSetHeader }
+
+// Header returns FlowSet ID and length.
+func (set *OptionsTemplateFlowSet) Header() FlowSetHeader { return set.FlowSetHeader }
+
+// Kind returns KindDataFlowSet.
+func (set *DataFlowSet) Kind() FlowSetKind { return KindDataFlowSet }
+
+// Kind returns KindTemplateFlowSet.
+func (set *TemplateFlowSet) Kind() FlowSetKind { return KindTemplateFlowSet }
+
+// Kind returns KindOptionsTemplateFlowSet.
+func (set *OptionsTemplateFlowSet) Kind() FlowSetKind { return KindOptionsTemplateFlowSet }
+
+func (set *DataFlowSet) accept(v Visitor) error { return v.VisitDataFlowSet(set) }
+
+func (set *TemplateFlowSet) accept(v Visitor) error { return v.VisitTemplateFlowSet(set) }
+
+func (set *OptionsTemplateFlowSet) accept(v Visitor) error { return v.VisitOptionsTemplateFlowSet(set) }
+
+// Visitor is called by Packet.Walk for every FlowSet of a packet.
+type Visitor interface {
+	VisitDataFlowSet(set *DataFlowSet) error
+	VisitTemplateFlowSet(set *TemplateFlowSet) error
+	VisitOptionsTemplateFlowSet(set *OptionsTemplateFlowSet) error
+}
+
+// VisitorFuncs implements Visitor using optional functions. FlowSets with no
+// function set are skipped.
+type VisitorFuncs struct {
+	DataFlowSet            func(set *DataFlowSet) error
+	TemplateFlowSet        func(set *TemplateFlowSet) error
+	OptionsTemplateFlowSet func(set *OptionsTemplateFlowSet) error
+}
+
+// VisitDataFlowSet calls f.DataFlowSet if it is set.
+func (f VisitorFuncs) VisitDataFlowSet(set *DataFlowSet) error {
+	if f.DataFlowSet == nil {
+		return nil
+	}
+	return f.DataFlowSet(set)
+}
+
+// VisitTemplateFlowSet calls f.TemplateFlowSet if it is set.
+func (f VisitorFuncs) VisitTemplateFlowSet(set *TemplateFlowSet) error {
+	if f.TemplateFlowSet == nil {
+		return nil
+	}
+	return f.TemplateFlowSet(set)
+}
+
+// VisitOptionsTemplateFlowSet calls f.OptionsTemplateFlowSet if it is set.
+func (f VisitorFuncs) VisitOptionsTemplateFlowSet(set *OptionsTemplateFlowSet) error {
+	if f.OptionsTemplateFlowSet == nil {
+		return nil
+	}
+	return f.OptionsTemplateFlowSet(set)
+}
+
+// Walk calls v for every FlowSet of the packet in packet order. Walking stops
+// at the first error returned by v.
+func (p *Packet) Walk(v Visitor) error {
+	for _, set := range p.FlowSets {
+		if err := set.accept(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
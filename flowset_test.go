@@ -0,0 +1,57 @@
+package nf9packet
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacketWalk(t *testing.T) {
+	p, err := Decode(testTemplatePacket)
+	require.NoError(t, err)
+	data, err := Decode(testDataPacket)
+	require.NoError(t, err)
+	p.FlowSets = append(p.FlowSets, data.FlowSets...)
+
+	var kinds []FlowSetKind
+	for _, set := range p.FlowSets {
+		kinds = append(kinds, set.Kind())
+	}
+	assert.Equal(t, []FlowSetKind{KindTemplateFlowSet, KindDataFlowSet}, kinds)
+	assert.Equal(t, FlowSetHeader{256, 16}, p.FlowSets[1].Header())
+
+	var templates, dataSets int
+	err = p.Walk(VisitorFuncs{
+		TemplateFlowSet: func(set *TemplateFlowSet) error {
+			templates += len(set.Records)
+			return nil
+		},
+		DataFlowSet: func(set *DataFlowSet) error {
+			dataSets++
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, templates)
+	assert.Equal(t, 1, dataSets)
+
+	stop := errors.New("stop")
+	err = p.Walk(VisitorFuncs{
+		TemplateFlowSet: func(set *TemplateFlowSet) error { return stop },
+		DataFlowSet: func(set *DataFlowSet) error {
+			t.Error("Walk did not stop")
+			return nil
+		},
+	})
+	assert.Equal(t, stop, err)
+}
+
+func TestPacketTemplateRecordsPointers(t *testing.T) {
+	p, err := Decode(testTemplatePacket)
+	require.NoError(t, err)
+
+	p.TemplateRecords()[0].TemplateId = 300
+	assert.Equal(t, uint16(300), p.FlowSets[0].(*TemplateFlowSet).Records[0].TemplateId)
+}
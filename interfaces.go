@@ -0,0 +1,151 @@
+package nf9packet
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Field type of egress interface ifIndex, INPUT_SNMP is defined along with
+// interface options.
+const fieldOutputSnmp = 14
+
+func errorInterfaceLine(line int, msg string) error {
+	return fmt.Errorf("Interface mapping line %d: %s.", line, msg)
+}
+
+// InterfaceEnricher resolves INPUT_SNMP and OUTPUT_SNMP ifIndex values of Flow
+// Data Records to interface names. Interfaces announced by exporters in
+// Options Data Records are preferred, static mappings are used for exporters
+// that do not announce their interfaces. Zero value InterfaceEnricher has no
+// tables and no static mappings. InterfaceEnricher is safe for concurrent use
+// by multiple goroutines.
+type InterfaceEnricher struct {
+	// Tables interfaces announced by exporters are looked up in. May be nil
+	// if only static mappings are used.
+	Tables *OptionsTables
+
+	mu     sync.RWMutex
+	static map[string]map[uint32]Interface
+}
+
+// NewInterfaceEnricher creates an enricher using interfaces from tables and no
+// static mappings.
+func NewInterfaceEnricher(tables *OptionsTables) *InterfaceEnricher {
+	return &InterfaceEnricher{
+		Tables: tables,
+		static: make(map[string]map[uint32]Interface),
+	}
+}
+
+// AddStatic adds static mapping of interface iface of exporter. Exporter is
+// either the exporter IP address, which matches all ports of the exporter, or
+// the full exporter address as used by Session.
+func (e *InterfaceEnricher) AddStatic(exporter string, iface Interface) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.static == nil {
+		e.static = make(map[string]map[uint32]Interface)
+	}
+	m, ok := e.static[exporter]
+	if !ok {
+		m = make(map[uint32]Interface)
+		e.static[exporter] = m
+	}
+	m[iface.Index] = iface
+}
+
+// LoadStatic reads static mappings from r. Every line holds exporter address,
+// ifIndex, interface name and optional description separated by white space,
+// the description lasts until the end of line:
+//
+//	# exporter    ifIndex  name      description
+//	192.0.2.1     7        Gi0/0/1   Uplink to core
+//	192.0.2.1     8        Gi0/0/2
+//
+// Empty lines and lines starting with # are ignored.
+func (e *InterfaceEnricher) LoadStatic(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == '#' {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) < 3 {
+			return errorInterfaceLine(line, "expected exporter, ifIndex and name")
+		}
+		index, err := strconv.ParseUint(fields[1], 10, 32)
+		if err != nil {
+			return errorInterfaceLine(line, "invalid ifIndex "+strconv.Quote(fields[1]))
+		}
+		iface := Interface{Index: uint32(index), Name: fields[2]}
+		if len(fields) > 3 {
+			// Keep white space inside the description.
+			rest := text
+			for _, f := range fields[:3] {
+				rest = strings.TrimSpace(rest)[len(f):]
+			}
+			iface.Description = strings.TrimSpace(rest)
+		}
+		e.AddStatic(fields[0], iface)
+	}
+	return scanner.Err()
+}
+
+// LoadStaticFile reads static mappings from the named file, see LoadStatic.
+func (e *InterfaceEnricher) LoadStaticFile(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return e.LoadStatic(f)
+}
+
+func (e *InterfaceEnricher) lookupStatic(addr string, index uint32) (Interface, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if iface, ok := e.static[addr][index]; ok {
+		return iface, true
+	}
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		iface, ok := e.static[host][index]
+		return iface, ok
+	}
+	return Interface{}, false
+}
+
+// Interface returns interface with ifIndex index of Observation Domain key.
+// If the interface is not known, only Index of the returned interface is set.
+func (e *InterfaceEnricher) Interface(key DomainKey, index uint32) (Interface, bool) {
+	if e.Tables != nil {
+		if iface, ok := e.Tables.Interface(key, index); ok {
+			return iface, true
+		}
+	}
+	if iface, ok := e.lookupStatic(key.Addr, index); ok {
+		return iface, true
+	}
+	return Interface{Index: index}, false
+}
+
+// Enrich returns input and output interfaces of Flow Data Record r decoded
+// with template tpl and received from Observation Domain key. Interfaces
+// missing from the record are returned with zero Index and empty names.
+func (e *InterfaceEnricher) Enrich(key DomainKey, tpl *TemplateRecord, r *FlowDataRecord) (input, output Interface) {
+	if v := findValue(tpl.Fields, r.Values, fieldInputSnmp); v != nil {
+		input, _ = e.Interface(key, uint32(fieldToUInteger(v)))
+	}
+	if v := findValue(tpl.Fields, r.Values, fieldOutputSnmp); v != nil {
+		output, _ = e.Interface(key, uint32(fieldToUInteger(v)))
+	}
+	return input, output
+}
@@ -0,0 +1,74 @@
+package nf9packet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testInterfaceMapping = `
+# exporter    ifIndex  name      description
+192.0.2.1     7        Gi0/0/1   Uplink to  core
+192.0.2.1     8        Gi0/0/2
+192.0.2.2:2055 7       eth0
+`
+
+func TestInterfaceEnricher(t *testing.T) {
+	tables := NewOptionsTables()
+	tables.LearnOptions("192.0.2.1:50000", &OptionsRecords{
+		&Packet{Version: 9, SourceId: 1},
+		&OptionsTemplateRecord{
+			TemplateId: 256,
+			Scopes:     []Field{{Type: 2, Length: 4}},
+			Options:    []Field{{Type: 82, Length: 8}},
+		},
+		[]OptionsDataRecord{{[][]byte{{0, 0, 0, 8}}, [][]byte{[]byte("Te0/1/0\x00")}}},
+	})
+
+	e := NewInterfaceEnricher(tables)
+	require.NoError(t, e.LoadStatic(strings.NewReader(testInterfaceMapping)))
+
+	tpl := &TemplateRecord{256, 2, []Field{{Type: 10, Length: 2}, {Type: 14, Length: 2}}}
+	rec := &FlowDataRecord{[][]byte{{0, 7}, {0, 8}}}
+
+	// Announced interfaces take precedence over static mappings.
+	input, output := e.Enrich(DomainKey{"192.0.2.1:50000", 1}, tpl, rec)
+	assert.Equal(t, Interface{Index: 7, Name: "Gi0/0/1", Description: "Uplink to  core"}, input)
+	assert.Equal(t, "Te0/1/0", output.Name)
+
+	// Other Observation Domains of the exporter only have static mappings.
+	input, output = e.Enrich(DomainKey{"192.0.2.1:50000", 2}, tpl, rec)
+	assert.Equal(t, "Gi0/0/1", input.Name)
+	assert.Equal(t, "Gi0/0/2", output.Name)
+
+	input, output = e.Enrich(DomainKey{"192.0.2.2:2055", 0}, tpl, rec)
+	assert.Equal(t, "eth0", input.Name)
+	assert.Equal(t, Interface{Index: 8}, output)
+
+	_, ok := e.Interface(DomainKey{"192.0.2.2:2056", 0}, 7)
+	assert.False(t, ok)
+}
+
+func TestInterfaceEnricherZeroValue(t *testing.T) {
+	var e InterfaceEnricher
+	_, ok := e.Interface(DomainKey{"192.0.2.1:50000", 1}, 7)
+	assert.False(t, ok)
+
+	require.NoError(t, e.LoadStatic(strings.NewReader(testInterfaceMapping)))
+	e.AddStatic("192.0.2.3", Interface{Index: 1, Name: "eth1"})
+
+	iface, ok := e.Interface(DomainKey{"192.0.2.1:50000", 1}, 7)
+	assert.True(t, ok)
+	assert.Equal(t, "Gi0/0/1", iface.Name)
+	iface, ok = e.Interface(DomainKey{"192.0.2.3:2055", 0}, 1)
+	assert.True(t, ok)
+	assert.Equal(t, "eth1", iface.Name)
+}
+
+func TestInterfaceEnricherLoadErrors(t *testing.T) {
+	e := NewInterfaceEnricher(nil)
+	assert.EqualError(t, e.LoadStatic(strings.NewReader("192.0.2.1 7\n")), "Interface mapping line 1: expected exporter, ifIndex and name.")
+	assert.EqualError(t, e.LoadStatic(strings.NewReader("\n192.0.2.1 Gi0/0/1 uplink\n")), `Interface mapping line 2: invalid ifIndex "Gi0/0/1".`)
+}
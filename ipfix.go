@@ -0,0 +1,238 @@
+package nf9packet
+
+import (
+	"encoding/binary"
+)
+
+// IPFIX Set IDs of Template Sets and Options Template Sets. Data Sets use
+// Template ID (256-65535) as Set ID, same as NetFlow v9 Data FlowSets.
+const (
+	IPFIXTemplateSetId        = 2
+	IPFIXOptionsTemplateSetId = 3
+)
+
+const (
+	ipfixHeaderLength = 16
+
+	// Enterprise bit of IPFIX field specifier Information Element
+	// identifier.
+	ipfixEnterpriseBit = 0x8000
+)
+
+// parseIPFIXFieldList parses count field specifiers starting at pos in data.
+// Offset is the position of data in the packet, it is used for error reporting
+// only. Position after the last field specifier is returned.
+func parseIPFIXFieldList(data []byte, pos, count, offset, index int) ([]Field, int, error) {
+	list := make([]Field, count)
+
+	for i := range list {
+		if len(data)-pos < fieldLength {
+			return nil, pos, errorMissingData(offset+pos, index, fieldLength-(len(data)-pos))
+		}
+		list[i].Type = binary.BigEndian.Uint16(data[pos:])
+		list[i].Length = binary.BigEndian.Uint16(data[pos+2:])
+		pos += fieldLength
+
+		if list[i].Type&ipfixEnterpriseBit != 0 {
+			if len(data)-pos < 4 {
+				return nil, pos, errorMissingData(offset+pos, index, 4-(len(data)-pos))
+			}
+			list[i].Type &^= ipfixEnterpriseBit
+			list[i].EnterpriseNumber = binary.BigEndian.Uint32(data[pos:])
+			pos += 4
+		}
+	}
+
+	return list, pos, nil
+}
+
+func ipfixFieldListLength(list []Field) (length uint16) {
+	for _, f := range list {
+		length += fieldLength
+		if f.EnterpriseNumber != 0 {
+			length += 4
+		}
+	}
+	return
+}
+
+func parseIPFIXTemplateSet(data []byte, header *FlowSetHeader, offset, index int) (FlowSet, error) {
+	var set TemplateFlowSet
+	var err error
+
+	set.FlowSetHeader = *header
+
+	for pos := 0; len(data)-pos >= 4; { // Padding is shorter than the smallest record
+		var t TemplateRecord
+
+		t.TemplateId = binary.BigEndian.Uint16(data[pos:])
+		t.FieldCount = binary.BigEndian.Uint16(data[pos+2:])
+
+		// Template Record with zero fields is a Template Withdrawal.
+		t.Fields, pos, err = parseIPFIXFieldList(data, pos+4, int(t.FieldCount), offset, index)
+		if err != nil {
+			return nil, err
+		}
+
+		set.Records = append(set.Records, t)
+	}
+	return &set, nil
+}
+
+func parseIPFIXOptionsTemplateSet(data []byte, header *FlowSetHeader, offset, index int) (FlowSet, error) {
+	var set OptionsTemplateFlowSet
+
+	set.FlowSetHeader = *header
+
+	for pos := 0; len(data)-pos >= 4; { // Padding is shorter than the smallest record
+		var t OptionsTemplateRecord
+		var scopeCount uint16
+		start := pos
+
+		t.TemplateId = binary.BigEndian.Uint16(data[pos:])
+		fieldCount := binary.BigEndian.Uint16(data[pos+2:])
+		pos += 4
+
+		// Options Template Withdrawal has no Scope Field Count.
+		if fieldCount > 0 {
+			if len(data)-pos < 2 {
+				return nil, errorMissingData(offset+pos, index, 2-(len(data)-pos))
+			}
+			scopeCount = binary.BigEndian.Uint16(data[pos:])
+			pos += 2
+		}
+		if scopeCount > fieldCount {
+			return nil, errorBadTemplate(offset+start, index)
+		}
+
+		fields, next, err := parseIPFIXFieldList(data, pos, int(fieldCount), offset, index)
+		if err != nil {
+			return nil, err
+		}
+		pos = next
+		t.Scopes = fields[:scopeCount]
+		t.Options = fields[scopeCount:]
+		t.ScopeLength = ipfixFieldListLength(t.Scopes)
+		t.OptionLength = ipfixFieldListLength(t.Options)
+
+		set.Records = append(set.Records, t)
+	}
+	return &set, nil
+}
+
+// parseIPFIXSet parses Set at offset in data and returns it together with its
+// length. Set length is returned even if its contents are invalid, zero
+// length means Set boundaries are unknown.
+func parseIPFIXSet(data []byte, offset, index int) (FlowSet, int, error) {
+	header, body, err := parseFlowSetHeader(data, offset, index)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var set FlowSet
+	offset += flowSetHeaderLength
+	switch {
+	case header.Id == IPFIXTemplateSetId:
+		set, err = parseIPFIXTemplateSet(body, &header, offset, index)
+	case header.Id == IPFIXOptionsTemplateSetId:
+		set, err = parseIPFIXOptionsTemplateSet(body, &header, offset, index)
+	case header.Id < 256:
+		// Set IDs 0 and 1 are not used for historical reasons and 4-255
+		// are reserved for future use.
+		err = errorReservedSetId(offset-flowSetHeaderLength, index)
+	default:
+		set, err = parseDataFlowSet(body, &header)
+	}
+	return set, int(header.Length), err
+}
+
+// DecodeIPFIX converts raw IPFIX (RFC 7011) message bytes to Packet struct.
+// IPFIX shares the template and data model of NetFlow v9, so the same
+// structures are used:
+//
+//	Packet.Version         10
+//	Packet.Count           number of Sets in the message
+//	Packet.SysUpTime       0, IPFIX has no such header field
+//	Packet.UnixSecs        Export Time
+//	Packet.SequenceNumber  Sequence Number
+//	Packet.SourceId        Observation Domain ID
+//
+// Template Sets are decoded to TemplateFlowSet, Options Template Sets to
+// OptionsTemplateFlowSet and Data Sets to DataFlowSet. FlowSetHeader.Id holds
+// the IPFIX Set ID. Enterprise-specific Information Elements have
+// Field.EnterpriseNumber set and variable length fields have Field.Length set
+// to VariableLength. Sets with a reserved Set ID (0, 1 and 4-255) are
+// rejected. Returned errors are of type *DecodeError.
+func DecodeIPFIX(data []byte) (*Packet, error) {
+	p, _, err := DecodeIPFIXWithOptions(data, DecodeOptions{})
+	return p, err
+}
+
+// DecodeIPFIXWithOptions converts raw IPFIX message bytes to Packet struct the
+// same way as DecodeIPFIX does, handling malformed messages and the input
+// buffer as DecodeWithOptions does for NetFlow v9 packets. In lenient mode
+// Sets with a reserved Set ID are skipped and reported in the list of
+// problems.
+func DecodeIPFIXWithOptions(data []byte, opts DecodeOptions) (*Packet, []error, error) {
+	var p Packet
+	var problems []error
+
+	// Create local copy of the "data" in case "data" slice is reused
+	// by the caller.
+	localData := data
+	if !opts.NoCopy {
+		localData = make([]byte, len(data))
+		copy(localData, data)
+	}
+
+	if len(localData) < ipfixHeaderLength {
+		return nil, nil, errorMissingData(len(localData), -1, ipfixHeaderLength-len(localData))
+	}
+	p.Version = binary.BigEndian.Uint16(localData[0:])
+	length := int(binary.BigEndian.Uint16(localData[2:]))
+	p.UnixSecs = binary.BigEndian.Uint32(localData[4:])
+	p.SequenceNumber = binary.BigEndian.Uint32(localData[8:])
+	p.SourceId = binary.BigEndian.Uint32(localData[12:])
+
+	if p.Version != 10 {
+		return nil, nil, errorIncompatibleVersion(p.Version)
+	}
+
+	if length < ipfixHeaderLength {
+		return nil, nil, errorBadFlowSetLength(2, -1, length)
+	}
+	if length > len(localData) {
+		return nil, nil, errorMissingData(len(localData), -1, length-len(localData))
+	}
+	if length < len(localData) {
+		err := errorExtraBytes(length, len(localData)-length)
+		if !opts.Lenient {
+			return nil, nil, err
+		}
+		problems = append(problems, err)
+		localData = localData[:length:length]
+	}
+
+	p.FlowSets = make([]FlowSet, 0)
+	for pos, i := ipfixHeaderLength, 0; pos < len(localData); i++ {
+		set, setLength, err := parseIPFIXSet(localData, pos, i)
+		if err != nil {
+			if !opts.Lenient {
+				return nil, nil, err
+			}
+			problems = append(problems, err)
+			if setLength == 0 {
+				// Set boundaries are unknown, nothing else can
+				// be decoded.
+				break
+			}
+			pos += setLength
+			continue
+		}
+		p.FlowSets = append(p.FlowSets, set)
+		pos += setLength
+	}
+	p.Count = uint16(len(p.FlowSets))
+
+	return &p, problems, nil
+}
@@ -0,0 +1,143 @@
+package nf9packet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testIPFIXMessage = []byte{
+	0x00, 0x0a, // Version
+	0x00, 0x5c, // Length (92)
+	0x00, 0x00, 0x02, 0x00, // Export time (512)
+	0x00, 0x00, 0x00, 0x05, // Sequence number
+	0x00, 0x00, 0x00, 0x07, // Observation domain ID
+
+	0x00, 0x02, 0x00, 0x18, // Template Set, length 24
+	0x01, 0x00, 0x00, 0x03, // Template 256, 3 fields
+	0x00, 0x08, 0x00, 0x04, // IPV4_SRC_ADDR, 4 bytes
+	0x80, 0x64, 0x00, 0x04, // Enterprise type 100, 4 bytes
+	0x00, 0x00, 0x00, 0x09, // PEN 9
+	0x00, 0x52, 0xff, 0xff, // IF_NAME, variable length
+
+	0x00, 0x03, 0x00, 0x14, // Options Template Set, length 20
+	0x01, 0x01, 0x00, 0x02, // Template 257, 2 fields
+	0x00, 0x01, // 1 scope field
+	0x00, 0x0a, 0x00, 0x02, // INPUT_SNMP, 2 bytes
+	0x00, 0x52, 0xff, 0xff, // IF_NAME, variable length
+	0x00, 0x00, // Padding
+
+	0x01, 0x00, 0x00, 0x20, // Data Set 256, length 32
+	0x0a, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x2a, // 10.0.0.1, 42
+	0x03, 'e', 't', 'h', // "eth"
+	0x0a, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x2b, // 10.0.0.2, 43
+	0xff, 0x00, 0x02, 'a', 'b', // "ab" with 3 byte length
+	0x00, 0x00, 0x00, // Padding
+}
+
+func TestDecodeIPFIX(t *testing.T) {
+	p, err := DecodeIPFIX(testIPFIXMessage)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(10), p.Version)
+	assert.Equal(t, uint16(3), p.Count)
+	assert.Equal(t, uint32(512), p.UnixSecs)
+	assert.Equal(t, uint32(5), p.SequenceNumber)
+	assert.Equal(t, uint32(7), p.SourceId)
+
+	templates := p.TemplateRecords()
+	require.Len(t, templates, 1)
+	assert.Equal(t, []Field{
+		{Type: 8, Length: 4},
+		{Type: 100, Length: 4, EnterpriseNumber: 9},
+		{Type: 82, Length: VariableLength},
+	}, templates[0].Fields)
+	assert.Equal(t, "ENTERPRISE_9_100", templates[0].Fields[1].Name())
+
+	options := p.OptionsTemplateRecords()
+	require.Len(t, options, 1)
+	assert.Equal(t, []Field{{Type: 10, Length: 2}}, options[0].Scopes)
+	assert.Equal(t, []Field{{Type: 82, Length: VariableLength}}, options[0].Options)
+
+	sp := NewSession().DecodePacket("exporter", p)
+	require.Len(t, sp.Flows, 1)
+	assert.Equal(t, []FlowDataRecord{
+		{[][]byte{{10, 0, 0, 1}, {0, 0, 0, 42}, []byte("eth")}},
+		{[][]byte{{10, 0, 0, 2}, {0, 0, 0, 43}, []byte("ab")}},
+	}, sp.Flows[0].Records)
+}
+
+func TestDecodeIPFIXWithdrawal(t *testing.T) {
+	withdrawal := []byte{
+		0x00, 0x0a, 0x00, 0x18, // Version, length 24
+		0x00, 0x00, 0x02, 0x00, // Export time
+		0x00, 0x00, 0x00, 0x06, // Sequence number
+		0x00, 0x00, 0x00, 0x07, // Observation domain ID
+		0x00, 0x02, 0x00, 0x08, // Template Set, length 8
+		0x01, 0x00, 0x00, 0x00, // Template 256 withdrawal
+	}
+
+	s := NewSession()
+	var events []TemplateEventType
+	s.Templates.OnEvent = func(ev TemplateEvent) {
+		events = append(events, ev.Type)
+	}
+
+	for _, data := range [][]byte{testIPFIXMessage, withdrawal} {
+		p, err := DecodeIPFIX(data)
+		require.NoError(t, err)
+		s.DecodePacket("exporter", p)
+	}
+	assert.Equal(t, []TemplateEventType{TemplateAdded, TemplateAdded, TemplateWithdrawn}, events)
+}
+
+func TestDecodeIPFIXErrors(t *testing.T) {
+	_, err := DecodeIPFIX(testIPFIXMessage[:len(testIPFIXMessage)-1])
+	assert.Error(t, err)
+
+	_, err = DecodeIPFIX(append(testIPFIXMessage, 0))
+	assert.Error(t, err)
+
+	_, err = DecodeIPFIX(testTemplatePacket)
+	assert.Error(t, err)
+}
+
+func TestDecodeIPFIXReservedSetId(t *testing.T) {
+	for _, id := range []byte{0, 1, 4, 255} {
+		data := append([]byte(nil), testIPFIXMessage...)
+		data[60], data[61] = 0, id // Data Set 256 becomes a reserved Set
+
+		_, err := DecodeIPFIX(data)
+		assert.ErrorIs(t, err, ErrReservedSetId)
+		var decodeErr *DecodeError
+		require.ErrorAs(t, err, &decodeErr)
+		assert.Equal(t, 60, decodeErr.Offset)
+		assert.Equal(t, 2, decodeErr.FlowSet)
+
+		p, problems, err := DecodeIPFIXWithOptions(data, DecodeOptions{Lenient: true})
+		require.NoError(t, err)
+		require.Len(t, problems, 1)
+		assert.ErrorIs(t, problems[0], ErrReservedSetId)
+		assert.Len(t, p.FlowSets, 2)
+		assert.Empty(t, p.DataFlowSets())
+	}
+}
+
+func TestDecodeIPFIXWithOptions(t *testing.T) {
+	data := append([]byte(nil), testIPFIXMessage...)
+	p, problems, err := DecodeIPFIXWithOptions(data, DecodeOptions{NoCopy: true})
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+	require.Len(t, p.DataFlowSets(), 1)
+
+	// Data Set refers to the input buffer.
+	data[64] = 0x0b
+	assert.Equal(t, byte(0x0b), p.DataFlowSets()[0].Data[0])
+
+	// Trailing bytes are ignored in lenient mode.
+	p, problems, err = DecodeIPFIXWithOptions(append(data, 0, 0), DecodeOptions{Lenient: true})
+	require.NoError(t, err)
+	require.Len(t, problems, 1)
+	assert.ErrorIs(t, problems[0], ErrTrailingBytes)
+	assert.Len(t, p.FlowSets, 3)
+}
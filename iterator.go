@@ -0,0 +1,128 @@
+package nf9packet
+
+// RecordIterator walks records of a Data FlowSet without allocating memory
+// for every record. Values returned by the iterator point directly into Data
+// FlowSet data and the slice holding them is reused, so they are only valid
+// until the next call to Next. Typical usage:
+//
+//	it := template.Records(set)
+//	for it.Next() {
+//		record := it.Record()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type RecordIterator struct {
+	data   []byte
+	setId  uint16
+	offset int
+	fields []Field
+	scopes int
+	values [][]byte
+	err    error
+}
+
+// Records returns an iterator over Flow Data Records in Data FlowSet set.
+// Errors are reported by RecordIterator.Err the same way as by DecodeRecords.
+func (dtpl *TemplateRecord) Records(set *DataFlowSet) RecordIterator {
+	if set.Id != dtpl.TemplateId {
+		return RecordIterator{err: errorTemplateMismatch(set.Id, dtpl.TemplateId)}
+	}
+	return RecordIterator{
+		data:   set.Data,
+		setId:  set.Id,
+		fields: dtpl.Fields,
+		values: make([][]byte, len(dtpl.Fields)),
+	}
+}
+
+// Records returns an iterator over Options Data Records in Data FlowSet set.
+// Errors are reported by RecordIterator.Err the same way as by DecodeRecords.
+func (otpl *OptionsTemplateRecord) Records(set *DataFlowSet) RecordIterator {
+	if set.Id != otpl.TemplateId {
+		return RecordIterator{err: errorTemplateMismatch(set.Id, otpl.TemplateId)}
+	}
+	fields := make([]Field, 0, len(otpl.Scopes)+len(otpl.Options))
+	fields = append(append(fields, otpl.Scopes...), otpl.Options...)
+	return RecordIterator{
+		data:   set.Data,
+		setId:  set.Id,
+		fields: fields,
+		scopes: len(otpl.Scopes),
+		values: make([][]byte, len(fields)),
+	}
+}
+
+// Next advances the iterator to the next record. It returns false when there
+// are no more records or an error occurred.
+func (it *RecordIterator) Next() bool {
+	// Assume total record length must be >= 4, otherwise it is impossible
+	// to distinguish between padding and new record. Padding MUST be
+	// supported.
+	if it.err != nil || len(it.data)-it.offset < 4 {
+		return false
+	}
+
+	pos := it.offset
+	for i := range it.fields {
+		value, size := fieldValue(it.data[pos:], &it.fields[i])
+		if size < 0 {
+			it.err = errorTruncatedRecord(it.setId, it.offset, len(it.data)-it.offset)
+			return false
+		}
+		it.values[i] = value
+		pos += size
+	}
+	if pos == it.offset {
+		// Template without data, records would never end.
+		return false
+	}
+	it.offset = pos
+	return true
+}
+
+// Record returns values of the current Flow Data Record.
+func (it *RecordIterator) Record() FlowDataRecord {
+	return FlowDataRecord{it.values}
+}
+
+// OptionsRecord returns values of the current Options Data Record.
+func (it *RecordIterator) OptionsRecord() OptionsDataRecord {
+	return OptionsDataRecord{it.values[:it.scopes], it.values[it.scopes:]}
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *RecordIterator) Err() error {
+	return it.err
+}
+
+func (it *RecordIterator) copyValues() [][]byte {
+	return append([][]byte(nil), it.values...)
+}
+
+// ForEachRecord calls fn for every Flow Data Record in Data FlowSet set.
+// Record values are only valid until fn returns. Iteration stops early if fn
+// returns false.
+func (dtpl *TemplateRecord) ForEachRecord(set *DataFlowSet, fn func(FlowDataRecord) bool) error {
+	it := dtpl.Records(set)
+	for it.Next() {
+		if !fn(it.Record()) {
+			break
+		}
+	}
+	return it.Err()
+}
+
+// ForEachRecord calls fn for every Options Data Record in Data FlowSet set.
+// Record values are only valid until fn returns. Iteration stops early if fn
+// returns false.
+func (otpl *OptionsTemplateRecord) ForEachRecord(set *DataFlowSet, fn func(OptionsDataRecord) bool) error {
+	it := otpl.Records(set)
+	for it.Next() {
+		if !fn(it.OptionsRecord()) {
+			break
+		}
+	}
+	return it.Err()
+}
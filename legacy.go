@@ -0,0 +1,187 @@
+package nf9packet
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Template IDs of synthetic templates describing NetFlow v5 and v7 records.
+const (
+	NetFlowV5TemplateId = 0xff05
+	NetFlowV7TemplateId = 0xff07
+)
+
+const (
+	legacyHeaderLength = 24
+	v5RecordLength     = 48
+	v7RecordLength     = 52
+)
+
+// legacyField maps a fixed offset in NetFlow v5/v7 record to NetFlow v9 field.
+type legacyField struct {
+	offset int
+	field  Field
+}
+
+var v5Fields = []legacyField{
+	{0, Field{Type: 8, Length: 4}},   // srcaddr
+	{4, Field{Type: 12, Length: 4}},  // dstaddr
+	{8, Field{Type: 15, Length: 4}},  // nexthop
+	{12, Field{Type: 10, Length: 2}}, // input
+	{14, Field{Type: 14, Length: 2}}, // output
+	{16, Field{Type: 2, Length: 4}},  // dPkts
+	{20, Field{Type: 1, Length: 4}},  // dOctets
+	{24, Field{Type: 22, Length: 4}}, // First
+	{28, Field{Type: 21, Length: 4}}, // Last
+	{32, Field{Type: 7, Length: 2}},  // srcport
+	{34, Field{Type: 11, Length: 2}}, // dstport
+	{37, Field{Type: 6, Length: 1}},  // tcp_flags
+	{38, Field{Type: 4, Length: 1}},  // prot
+	{39, Field{Type: 5, Length: 1}},  // tos
+	{40, Field{Type: 16, Length: 2}}, // src_as
+	{42, Field{Type: 17, Length: 2}}, // dst_as
+	{44, Field{Type: 9, Length: 1}},  // src_mask
+	{45, Field{Type: 13, Length: 1}}, // dst_mask
+}
+
+// NetFlow v7 record is a v5 record with a different meaning of the padding
+// bytes and the router_sc address appended. Flags and router_sc have no
+// NetFlow v9 equivalent and are not exposed.
+var v7Fields = v5Fields
+
+// NetFlow v5 header fields appended to every record.
+var v5HeaderFields = []Field{
+	{Type: 38, Length: 1}, // ENGINE_TYPE
+	{Type: 39, Length: 1}, // ENGINE_ID
+	{Type: 35, Length: 1}, // SAMPLING_ALGORITHM
+	{Type: 34, Length: 4}, // SAMPLING_INTERVAL
+}
+
+func legacyTemplate(id uint16, fields []legacyField, extra []Field) TemplateRecord {
+	t := TemplateRecord{TemplateId: id}
+	for _, f := range fields {
+		t.Fields = append(t.Fields, f.field)
+	}
+	t.Fields = append(t.Fields, extra...)
+	t.FieldCount = uint16(len(t.Fields))
+	return t
+}
+
+// decodeLegacy decodes NetFlow v5/v7 header and records. Records are rebuilt
+// to match the synthetic template: padding is dropped and header bytes from
+// the extra slice are appended to every record.
+func decodeLegacy(data []byte, version uint16, recordLength int, templateId uint16, fields []legacyField, extraFields []Field, extra []byte) (*Packet, error) {
+	var p Packet
+
+	if len(data) < legacyHeaderLength {
+		return nil, errorMissingData(len(data), -1, legacyHeaderLength-len(data))
+	}
+
+	p.Version = binary.BigEndian.Uint16(data[0:])
+	p.Count = binary.BigEndian.Uint16(data[2:])
+	p.SysUpTime = binary.BigEndian.Uint32(data[4:])
+	p.UnixSecs = binary.BigEndian.Uint32(data[8:])
+	p.SequenceNumber = binary.BigEndian.Uint32(data[16:])
+
+	if p.Version != version {
+		return nil, errorIncompatibleVersion(p.Version)
+	}
+
+	expected := legacyHeaderLength + int(p.Count)*recordLength
+	if len(data) < expected {
+		return nil, errorMissingData(len(data), -1, expected-len(data))
+	}
+	if len(data) > expected {
+		return nil, errorExtraBytes(expected, len(data)-expected)
+	}
+
+	template := legacyTemplate(templateId, fields, extraFields)
+	records := make([]byte, 0, int(p.Count)*(recordLength+len(extra)))
+	for i := 0; i < int(p.Count); i++ {
+		record := data[legacyHeaderLength+i*recordLength:]
+		for _, f := range fields {
+			records = append(records, record[f.offset:f.offset+int(f.field.Length)]...)
+		}
+		records = append(records, extra...)
+	}
+	// Records must fit into a single Data FlowSet. NetFlow v5 and v7
+	// packets hold at most 30 records, so only a bogus Count gets here.
+	length := flowSetHeaderLength + len(records)
+	if length > math.MaxUint16 {
+		return nil, errorBadFlowSetLength(2, -1, length)
+	}
+
+	p.FlowSets = []FlowSet{
+		NewTemplateFlowSet(template),
+		&DataFlowSet{
+			FlowSetHeader: FlowSetHeader{templateId, uint16(length)},
+			Data:          records,
+		},
+	}
+
+	return &p, nil
+}
+
+// DecodeV5 converts raw NetFlow v5 packet bytes to Packet struct. NetFlow v5
+// records have a fixed format, so the packet is represented as if it was a
+// NetFlow v9 packet containing a single Template FlowSet, describing records
+// with NetFlow v9 field types (IPV4_SRC_ADDR, L4_SRC_PORT, IN_BYTES, ...),
+// and a single Data FlowSet with all the records. Template ID is
+// NetFlowV5TemplateId. Header fields engine_type, engine_id and sampling
+// interval are added to every record as ENGINE_TYPE, ENGINE_ID,
+// SAMPLING_ALGORITHM and SAMPLING_INTERVAL fields. SourceId is set to
+// engine_type and engine_id combined into a single number. Packet Count is the
+// number of Flow Data Records.
+func DecodeV5(data []byte) (*Packet, error) {
+	var extra []byte
+	var sourceId uint32
+
+	if len(data) >= legacyHeaderLength {
+		sampling := binary.BigEndian.Uint16(data[22:])
+		extra = []byte{
+			data[20],             // engine_type
+			data[21],             // engine_id
+			byte(sampling >> 14), // sampling mode
+			0, 0, byte(sampling >> 8 & 0x3f), byte(sampling),
+		}
+		sourceId = uint32(data[20])<<8 | uint32(data[21])
+	}
+
+	p, err := decodeLegacy(data, 5, v5RecordLength, NetFlowV5TemplateId, v5Fields, v5HeaderFields, extra)
+	if err != nil {
+		return nil, err
+	}
+	p.SourceId = sourceId
+	return p, nil
+}
+
+// DecodeV7 converts raw NetFlow v7 (Catalyst) packet bytes to Packet struct.
+// Packet is represented the same way as DecodeV5 does, with
+// NetFlowV7TemplateId as Template ID. NetFlow v7 header has no engine and
+// sampling fields, so SourceId is always zero.
+func DecodeV7(data []byte) (*Packet, error) {
+	return decodeLegacy(data, 7, v7RecordLength, NetFlowV7TemplateId, v7Fields, nil, nil)
+}
+
+// DecodeAny checks protocol version of raw packet bytes and decodes them using
+// DecodeV5, DecodeV7, Decode (NetFlow v9) or DecodeIPFIX. All of them produce
+// Packet struct with Template Records and Data FlowSets, so records of all
+// versions can be handled the same way.
+func DecodeAny(data []byte) (*Packet, error) {
+	if len(data) < 2 {
+		return nil, errorMissingData(len(data), -1, 2-len(data))
+	}
+
+	switch version := binary.BigEndian.Uint16(data); version {
+	case 5:
+		return DecodeV5(data)
+	case 7:
+		return DecodeV7(data)
+	case 9:
+		return Decode(data)
+	case 10:
+		return DecodeIPFIX(data)
+	default:
+		return nil, errorIncompatibleVersion(version)
+	}
+}
@@ -0,0 +1,112 @@
+package nf9packet
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testV5Packet = []byte{
+	0x00, 0x05, // Version
+	0x00, 0x01, // Count
+	0x00, 0x00, 0x10, 0x00, // System uptime
+	0x00, 0x00, 0x02, 0x00, // Unix seconds
+	0x00, 0x00, 0x00, 0x00, // Unix nanoseconds
+	0x00, 0x00, 0x00, 0x09, // Flow sequence
+	0x01, 0x02, // Engine type and ID
+	0x40, 0x64, // Sampling: deterministic, 1 out of 100
+
+	0x0a, 0x00, 0x00, 0x01, // srcaddr
+	0x0a, 0x00, 0x00, 0x02, // dstaddr
+	0x0a, 0x00, 0x00, 0xfe, // nexthop
+	0x00, 0x03, 0x00, 0x04, // input, output
+	0x00, 0x00, 0x00, 0x0a, // dPkts
+	0x00, 0x00, 0x10, 0x00, // dOctets
+	0x00, 0x00, 0x0f, 0x00, // First
+	0x00, 0x00, 0x0f, 0xf0, // Last
+	0x04, 0x00, 0x00, 0x50, // srcport, dstport
+	0x00, 0x1b, 0x06, 0x00, // pad1, tcp_flags, prot, tos
+	0xfd, 0xe8, 0x00, 0x0f, // src_as, dst_as
+	0x18, 0x10, 0x00, 0x00, // src_mask, dst_mask, pad2
+}
+
+func TestDecodeV5(t *testing.T) {
+	p, err := DecodeAny(testV5Packet)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(5), p.Version)
+	assert.Equal(t, uint32(9), p.SequenceNumber)
+	assert.Equal(t, uint32(0x0102), p.SourceId)
+
+	sp := NewSession().DecodePacket("exporter", p)
+	require.Len(t, sp.Flows, 1)
+	require.Len(t, sp.Flows[0].Records, 1)
+	assert.Equal(t, uint16(NetFlowV5TemplateId), sp.Flows[0].Template.TemplateId)
+
+	flow := sp.Flows[0].Records[0].ToMap(sp.Flows[0].Template)
+	assert.Equal(t, netip.MustParseAddr("10.0.0.1"), flow["IPV4_SRC_ADDR"])
+	assert.Equal(t, netip.MustParseAddr("10.0.0.254"), flow["IPV4_NEXT_HOP"])
+	assert.Equal(t, uint64(1024), flow["L4_SRC_PORT"])
+	assert.Equal(t, uint64(80), flow["L4_DST_PORT"])
+	assert.Equal(t, uint64(4096), flow["IN_BYTES"])
+	assert.Equal(t, uint64(10), flow["IN_PKTS"])
+	assert.Equal(t, uint64(6), flow["PROTOCOL"])
+	assert.Equal(t, TCPFlagSYN|TCPFlagACK|TCPFlagFIN|TCPFlagPSH, flow["TCP_FLAGS"])
+	assert.Equal(t, uint64(65000), flow["SRC_AS"])
+	assert.Equal(t, uint64(24), flow["SRC_MASK"])
+	assert.Equal(t, uint64(2), flow["ENGINE_ID"])
+	assert.Equal(t, uint64(1), flow["SAMPLING_ALGORITHM"])
+	assert.Equal(t, uint64(100), flow["SAMPLING_INTERVAL"])
+}
+
+func TestDecodeV7(t *testing.T) {
+	data := append([]byte(nil), testV5Packet...)
+	data[1] = 7
+	data = append(data, 0x0a, 0x00, 0x00, 0x03) // router_sc
+
+	p, err := DecodeAny(data)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(7), p.Version)
+	assert.Equal(t, uint32(0), p.SourceId)
+
+	templates := p.TemplateRecords()
+	require.Len(t, templates, 1)
+	sets := p.DataFlowSets()
+	require.Len(t, sets, 1)
+	records := templates[0].DecodeFlowSet(sets[0])
+	require.Len(t, records, 1)
+	assert.Equal(t, uint64(4096), records[0].ToMap(templates[0])["IN_BYTES"])
+}
+
+func TestDecodeLegacyErrors(t *testing.T) {
+	_, err := DecodeV5(testV5Packet[:len(testV5Packet)-1])
+	assert.Error(t, err)
+
+	_, err = DecodeV5(append(testV5Packet, 0))
+	assert.Error(t, err)
+
+	_, err = DecodeV7(testV5Packet)
+	assert.Error(t, err)
+
+	_, err = DecodeAny([]byte{0x00, 0x08})
+	assert.Error(t, err)
+
+	_, err = DecodeAny([]byte{0x00})
+	assert.Error(t, err)
+
+	// Records do not fit into a single Data FlowSet.
+	data := append([]byte(nil), testV5Packet[:legacyHeaderLength]...)
+	binary.BigEndian.PutUint16(data[2:], 1300)
+	data = append(data, make([]byte, 1300*v5RecordLength)...)
+	_, err = DecodeV5(data)
+	assert.ErrorIs(t, err, ErrBadFlowSetLength)
+
+	// The largest Count that fits.
+	binary.BigEndian.PutUint16(data[2:], 1260)
+	p, err := DecodeV5(data[:legacyHeaderLength+1260*v5RecordLength])
+	require.NoError(t, err)
+	set := p.DataFlowSets()[0]
+	assert.Equal(t, len(set.Data)+4, int(set.Length))
+}
@@ -0,0 +1,394 @@
+package nf9packet
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// NetFlow v9 Scope Field types of Options Template Records.
+const (
+	ScopeSystem uint16 = iota + 1
+	ScopeInterface
+	ScopeLineCard
+	ScopeCache
+	ScopeTemplate
+)
+
+// IPFIX scope Information Elements equivalent to NetFlow v9 scope types.
+var ipfixScopes = map[uint16]uint16{
+	10:  ScopeInterface, // ingressInterface
+	14:  ScopeInterface, // egressInterface
+	141: ScopeLineCard,  // lineCardId
+	143: ScopeSystem,    // meteringProcessId
+	144: ScopeSystem,    // exportingProcessId
+	145: ScopeTemplate,  // templateId
+	149: ScopeSystem,    // observationDomainId
+}
+
+// Field types of options describing interfaces, samplers and applications.
+const (
+	fieldInputSnmp                 = 10
+	fieldFlowSamplerId             = 48
+	fieldFlowSamplerMode           = 49
+	fieldFlowSamplerRandomInterval = 50
+	fieldIfName                    = 82
+	fieldIfDesc                    = 83
+	fieldSamplerName               = 84
+	fieldApplicationDescription    = 94
+	fieldApplicationTag            = 95
+	fieldApplicationName           = 96
+)
+
+// Scope is the part of the exporter an Options Data Record refers to.
+type Scope struct {
+	// One of ScopeSystem, ScopeInterface, ScopeLineCard, ScopeCache or
+	// ScopeTemplate. IPFIX scope Information Elements without NetFlow v9
+	// equivalent are kept as is.
+	Type uint16
+
+	// Scope field value, for example ifIndex of ScopeInterface.
+	Value uint64
+}
+
+// String returns scope name and value, for example "Interface 7".
+func (s Scope) String() string {
+	f := Field{Type: s.Type}
+	return fmt.Sprintf("%s %d", f.ScopeName(), s.Value)
+}
+
+// Interface is an interface announced by an exporter in Options Data Records.
+type Interface struct {
+	Scope Scope
+
+	// SNMP ifIndex as found in INPUT_SNMP and OUTPUT_SNMP fields of Flow
+	// Data Records.
+	Index uint32
+
+	// IF_NAME and IF_DESC, empty if not sent by the exporter.
+	Name        string
+	Description string
+}
+
+// Sampler is a flow sampler announced by an exporter in Options Data Records.
+type Sampler struct {
+	Scope Scope
+
+	// FLOW_SAMPLER_ID referred to by Flow Data Records.
+	Id uint64
+
+	// FLOW_SAMPLER_MODE: 0x01 deterministic, 0x02 random sampling.
+	Mode uint8
+
+	// FLOW_SAMPLER_RANDOM_INTERVAL, or SAMPLING_INTERVAL if the exporter
+	// sends only that, one of every Interval packets is sampled.
+	Interval uint32
+
+	// SAMPLER_NAME, empty if not sent by the exporter.
+	Name string
+}
+
+// Application is an application announced by an exporter in Options Data
+// Records.
+type Application struct {
+	Scope Scope
+
+	// APPLICATION_TAG as found in Flow Data Records.
+	Tag []byte
+
+	// APPLICATION_NAME and APPLICATION_DESCRIPTION, empty if not sent by
+	// the exporter.
+	Name        string
+	Description string
+}
+
+// Entries are stored per scope, while lookups by ID return the entry learned
+// last from any scope, as Flow Data Records do not refer to scopes.
+type interfaceKey struct {
+	scope Scope
+	index uint32
+}
+
+type samplerKey struct {
+	scope Scope
+	id    uint64
+}
+
+type applicationKey struct {
+	scope Scope
+	tag   string
+}
+
+type optionsDomain struct {
+	interfaces   map[interfaceKey]Interface
+	samplers     map[samplerKey]Sampler
+	applications map[applicationKey]Application
+
+	lastInterface   map[uint32]Interface
+	lastSampler     map[uint64]Sampler
+	lastApplication map[string]Application
+
+	// Observation Domain wide SAMPLING_INTERVAL, zero if not known.
+	samplingInterval uint32
+}
+
+func newOptionsDomain() *optionsDomain {
+	return &optionsDomain{
+		interfaces:      make(map[interfaceKey]Interface),
+		samplers:        make(map[samplerKey]Sampler),
+		applications:    make(map[applicationKey]Application),
+		lastInterface:   make(map[uint32]Interface),
+		lastSampler:     make(map[uint64]Sampler),
+		lastApplication: make(map[string]Application),
+	}
+}
+
+// OptionsTables interprets Options Data Records and keeps tables of
+// interfaces, samplers and applications announced by every Observation
+// Domain. OptionsTables is safe for concurrent use by multiple goroutines.
+type OptionsTables struct {
+	mu      sync.RWMutex
+	domains map[DomainKey]*optionsDomain
+}
+
+// NewOptionsTables creates empty tables.
+func NewOptionsTables() *OptionsTables {
+	return &OptionsTables{
+		domains: make(map[DomainKey]*optionsDomain),
+	}
+}
+
+// optionValue returns value of the first option field of type fieldType. IPFIX
+// scope fields are Information Elements too, so they are searched as well.
+func optionValue(version uint16, tpl *OptionsTemplateRecord, r *OptionsDataRecord, fieldType uint16) []byte {
+	if v := findValue(tpl.Options, r.OptionValues, fieldType); v != nil {
+		return v
+	}
+	if version == 10 {
+		return findValue(tpl.Scopes, r.ScopeValues, fieldType)
+	}
+	return nil
+}
+
+// recordScope returns scope of Options Data Record r, taken from its first
+// scope field.
+func recordScope(version uint16, tpl *OptionsTemplateRecord, r *OptionsDataRecord) Scope {
+	if len(tpl.Scopes) == 0 || len(r.ScopeValues) == 0 {
+		return Scope{}
+	}
+	s := Scope{tpl.Scopes[0].Type, fieldToUInteger(r.ScopeValues[0])}
+	if version == 10 {
+		if t, ok := ipfixScopes[s.Type]; ok {
+			s.Type = t
+		}
+	}
+	return s
+}
+
+func (t *OptionsTables) domain(key DomainKey) *optionsDomain {
+	d, ok := t.domains[key]
+	if !ok {
+		d = newOptionsDomain()
+		t.domains[key] = d
+	}
+	return d
+}
+
+// LearnOptions interprets Options Data Records received from exporter addr.
+// Records with IF_NAME or IF_DESC describe interfaces, their ifIndex is taken
+// from INPUT_SNMP or the Interface scope. Records with FLOW_SAMPLER_ID
+// describe samplers and records with APPLICATION_TAG describe applications.
+// SAMPLING_INTERVAL without FLOW_SAMPLER_ID applies to the whole Observation
+// Domain. Entries with the same scope and ID replace older ones, other records
+// are ignored.
+func (t *OptionsTables) LearnOptions(addr string, o *OptionsRecords) {
+	key := DomainKey{addr, o.Packet.SourceId}
+	version := o.Packet.Version
+	value := func(r *OptionsDataRecord, fieldType uint16) []byte {
+		return optionValue(version, o.Template, r, fieldType)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i := range o.Records {
+		r := &o.Records[i]
+		scope := recordScope(version, o.Template, r)
+
+		name, desc := value(r, fieldIfName), value(r, fieldIfDesc)
+		if name != nil || desc != nil {
+			iface := Interface{Scope: scope, Name: asciiString(name), Description: asciiString(desc)}
+			known := true
+			if index := value(r, fieldInputSnmp); index != nil {
+				iface.Index = uint32(fieldToUInteger(index))
+			} else if scope.Type == ScopeInterface {
+				iface.Index = uint32(scope.Value)
+			} else {
+				known = false
+			}
+			if known {
+				d := t.domain(key)
+				d.interfaces[interfaceKey{scope, iface.Index}] = iface
+				d.lastInterface[iface.Index] = iface
+			}
+		}
+
+		if id := value(r, fieldFlowSamplerId); id != nil {
+			s := Sampler{Scope: scope, Id: fieldToUInteger(id)}
+			if v := value(r, fieldFlowSamplerMode); v != nil {
+				s.Mode = uint8(fieldToUInteger(v))
+			}
+			if v := value(r, fieldFlowSamplerRandomInterval); v != nil {
+				s.Interval = uint32(fieldToUInteger(v))
+			} else if v := value(r, fieldSamplingInterval); v != nil {
+				s.Interval = uint32(fieldToUInteger(v))
+			}
+			s.Name = asciiString(value(r, fieldSamplerName))
+			d := t.domain(key)
+			d.samplers[samplerKey{scope, s.Id}] = s
+			d.lastSampler[s.Id] = s
+		} else if v := value(r, fieldSamplingInterval); v != nil {
+			t.domain(key).samplingInterval = uint32(fieldToUInteger(v))
+		}
+
+		if tag := value(r, fieldApplicationTag); tag != nil {
+			app := Application{
+				Scope:       scope,
+				Tag:         append([]byte(nil), tag...),
+				Name:        asciiString(value(r, fieldApplicationName)),
+				Description: asciiString(value(r, fieldApplicationDescription)),
+			}
+			d := t.domain(key)
+			d.applications[applicationKey{scope, string(tag)}] = app
+			d.lastApplication[string(tag)] = app
+		}
+	}
+}
+
+// Learn interprets all Options Data Records of packet sp received from
+// exporter addr.
+func (t *OptionsTables) Learn(addr string, sp *SessionPacket) {
+	for i := range sp.Options {
+		t.LearnOptions(addr, &sp.Options[i])
+	}
+}
+
+// Interface returns interface with ifIndex index learned last from
+// Observation Domain key.
+func (t *OptionsTables) Interface(key DomainKey, index uint32) (Interface, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if d, ok := t.domains[key]; ok {
+		iface, ok := d.lastInterface[index]
+		return iface, ok
+	}
+	return Interface{}, false
+}
+
+// Sampler returns sampler id learned last from Observation Domain key.
+func (t *OptionsTables) Sampler(key DomainKey, id uint64) (Sampler, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if d, ok := t.domains[key]; ok {
+		s, ok := d.lastSampler[id]
+		return s, ok
+	}
+	return Sampler{}, false
+}
+
+// Application returns application with APPLICATION_TAG tag learned last from
+// Observation Domain key.
+func (t *OptionsTables) Application(key DomainKey, tag []byte) (Application, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if d, ok := t.domains[key]; ok {
+		app, ok := d.lastApplication[string(tag)]
+		return app, ok
+	}
+	return Application{}, false
+}
+
+// SamplingInterval returns SAMPLING_INTERVAL announced for the whole
+// Observation Domain key.
+func (t *OptionsTables) SamplingInterval(key DomainKey) (uint32, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if d, ok := t.domains[key]; ok && d.samplingInterval != 0 {
+		return d.samplingInterval, true
+	}
+	return 0, false
+}
+
+func scopeLess(a, b Scope) bool {
+	if a.Type != b.Type {
+		return a.Type < b.Type
+	}
+	return a.Value < b.Value
+}
+
+// Interfaces returns all interfaces of Observation Domain key ordered by scope
+// and ifIndex.
+func (t *OptionsTables) Interfaces(key DomainKey) []Interface {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var list []Interface
+	if d, ok := t.domains[key]; ok {
+		for _, iface := range d.interfaces {
+			list = append(list, iface)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Scope != list[j].Scope {
+			return scopeLess(list[i].Scope, list[j].Scope)
+		}
+		return list[i].Index < list[j].Index
+	})
+	return list
+}
+
+// Samplers returns all samplers of Observation Domain key ordered by scope
+// and ID.
+func (t *OptionsTables) Samplers(key DomainKey) []Sampler {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var list []Sampler
+	if d, ok := t.domains[key]; ok {
+		for _, s := range d.samplers {
+			list = append(list, s)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Scope != list[j].Scope {
+			return scopeLess(list[i].Scope, list[j].Scope)
+		}
+		return list[i].Id < list[j].Id
+	})
+	return list
+}
+
+// Applications returns all applications of Observation Domain key ordered by
+// scope and tag.
+func (t *OptionsTables) Applications(key DomainKey) []Application {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var list []Application
+	if d, ok := t.domains[key]; ok {
+		for _, app := range d.applications {
+			list = append(list, app)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Scope != list[j].Scope {
+			return scopeLess(list[i].Scope, list[j].Scope)
+		}
+		return string(list[i].Tag) < string(list[j].Tag)
+	})
+	return list
+}
@@ -0,0 +1,105 @@
+package nf9packet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testOptionsRecords(version uint16, tpl *OptionsTemplateRecord, records ...OptionsDataRecord) *OptionsRecords {
+	return &OptionsRecords{&Packet{Version: version, SourceId: 1}, tpl, records}
+}
+
+func TestOptionsTablesInterfaces(t *testing.T) {
+	tables := NewOptionsTables()
+	key := DomainKey{"exporter", 1}
+
+	// Interface table with System scope and INPUT_SNMP option.
+	tables.LearnOptions("exporter", testOptionsRecords(9, &OptionsTemplateRecord{
+		TemplateId: 256,
+		Scopes:     []Field{{Type: 1, Length: 4}},
+		Options:    []Field{{Type: 10, Length: 4}, {Type: 82, Length: 8}, {Type: 83, Length: 24}},
+	},
+		OptionsDataRecord{[][]byte{{10, 0, 0, 1}}, [][]byte{{0, 0, 0, 7}, []byte("Gi0/0/1\x00"), []byte("GigabitEthernet0/0/1\x00\x00\x00\x00")}},
+		OptionsDataRecord{[][]byte{{10, 0, 0, 1}}, [][]byte{{0, 0, 0, 8}, []byte("Gi0/0/2\x00"), make([]byte, 24)}},
+	))
+	// Interface scope holding ifIndex.
+	tables.LearnOptions("exporter", testOptionsRecords(9, &OptionsTemplateRecord{
+		TemplateId: 257,
+		Scopes:     []Field{{Type: 2, Length: 4}},
+		Options:    []Field{{Type: 82, Length: 8}},
+	},
+		OptionsDataRecord{[][]byte{{0, 0, 0, 9}}, [][]byte{[]byte("Te0/1/0\x00")}},
+	))
+
+	iface, ok := tables.Interface(key, 7)
+	require.True(t, ok)
+	assert.Equal(t, Interface{Scope{ScopeSystem, 0x0a000001}, 7, "Gi0/0/1", "GigabitEthernet0/0/1"}, iface)
+
+	iface, ok = tables.Interface(key, 9)
+	require.True(t, ok)
+	assert.Equal(t, Interface{Scope{ScopeInterface, 9}, 9, "Te0/1/0", ""}, iface)
+	assert.Equal(t, "Interface 9", iface.Scope.String())
+
+	_, ok = tables.Interface(key, 10)
+	assert.False(t, ok)
+	_, ok = tables.Interface(DomainKey{"exporter", 2}, 7)
+	assert.False(t, ok)
+
+	list := tables.Interfaces(key)
+	require.Len(t, list, 3)
+	assert.Equal(t, []uint32{7, 8, 9}, []uint32{list[0].Index, list[1].Index, list[2].Index})
+}
+
+func TestOptionsTablesSamplersApplications(t *testing.T) {
+	tables := NewOptionsTables()
+	key := DomainKey{"exporter", 1}
+
+	tables.LearnOptions("exporter", testOptionsRecords(9, &testSamplerTemplate,
+		testSamplerRecord(2, 100, ""),
+		testSamplerRecord(1, 1000, "sampler-1k"),
+	))
+	tables.LearnOptions("exporter", testOptionsRecords(9, &OptionsTemplateRecord{
+		TemplateId: 258,
+		Scopes:     []Field{{Type: 1, Length: 4}},
+		Options:    []Field{{Type: 95, Length: 4}, {Type: 96, Length: 8}, {Type: 94, Length: 16}},
+	},
+		OptionsDataRecord{[][]byte{{0, 0, 0, 1}}, [][]byte{{3, 0, 0, 80}, []byte("http\x00\x00\x00\x00"), []byte("World Wide Web\x00\x00")}},
+	))
+
+	sampler, ok := tables.Sampler(key, 1)
+	require.True(t, ok)
+	assert.Equal(t, Sampler{Scope{ScopeSystem, 1}, 1, 2, 1000, "sampler-1k"}, sampler)
+
+	samplers := tables.Samplers(key)
+	require.Len(t, samplers, 2)
+	assert.Equal(t, uint64(1), samplers[0].Id)
+
+	app, ok := tables.Application(key, []byte{3, 0, 0, 80})
+	require.True(t, ok)
+	assert.Equal(t, "http", app.Name)
+	assert.Equal(t, "World Wide Web", app.Description)
+	assert.Len(t, tables.Applications(key), 1)
+
+	_, ok = tables.SamplingInterval(key)
+	assert.False(t, ok)
+}
+
+func TestOptionsTablesIPFIX(t *testing.T) {
+	tables := NewOptionsTables()
+
+	// IPFIX scope fields are Information Elements: ingressInterface scope
+	// doubles as the interface index.
+	tables.LearnOptions("exporter", testOptionsRecords(10, &OptionsTemplateRecord{
+		TemplateId: 256,
+		Scopes:     []Field{{Type: 10, Length: 4}},
+		Options:    []Field{{Type: 82, Length: 4}},
+	},
+		OptionsDataRecord{[][]byte{{0, 0, 0, 3}}, [][]byte{[]byte("eth0")}},
+	))
+
+	iface, ok := tables.Interface(DomainKey{"exporter", 1}, 3)
+	require.True(t, ok)
+	assert.Equal(t, Interface{Scope{ScopeInterface, 3}, 3, "eth0", ""}, iface)
+}
@@ -1,10 +1,11 @@
 // Package nf9packet provides structures and functions to decode and analyze
 // NetFlow v9 packets.
 //
-// This package does only packet decoding in a single packet context. It keeps
-// no state when decoding multiple packets. As a result Data FlowSets can not be
-// decoded during initial packet decoding. To decode Data FlowSets user must
-// keep track of Template Records and Options Template Records manually.
+// Function Decode does only packet decoding in a single packet context. It
+// keeps no state when decoding multiple packets. As a result Data FlowSets can
+// not be decoded during initial packet decoding. To decode Data FlowSets user
+// must keep track of Template Records and Options Template Records, either
+// manually or by using Session which does this automatically.
 //
 // Examples of NetFlow v9 packets:
 //
@@ -153,9 +154,9 @@ type Packet struct {
 	// A 32-bit value that identifies the Exporter Observation Domain.
 	SourceId uint32
 
-	// A slice of structs. Each element is instance of DataFlowSet or
-	// TemplateFlowSet or OptionsTemplateFlowSet.
-	FlowSets []interface{}
+	// FlowSets of the packet in packet order. Each element is a
+	// *DataFlowSet, *TemplateFlowSet or *OptionsTemplateFlowSet.
+	FlowSets []FlowSet
 }
 
 // DataFlowSet is a collection of Data Records (actual NetFlow data) and Options
@@ -201,10 +202,10 @@ type FlowSetHeader struct {
 // DataFlowSets generate a list of all Data FlowSets in the packet. If matched
 // with appropriate templates Data FlowSets can be decoded to Data Records or
 // Options Data Records.
-func (p *Packet) DataFlowSets() (list []DataFlowSet) {
+func (p *Packet) DataFlowSets() (list []*DataFlowSet) {
 	for i := range p.FlowSets {
 		switch set := p.FlowSets[i].(type) {
-		case DataFlowSet:
+		case *DataFlowSet:
 			list = append(list, set)
 		}
 	}
@@ -213,10 +214,11 @@ func (p *Packet) DataFlowSets() (list []DataFlowSet) {
 
 // TemplateRecords generate a list of all Template Records in the packet.
 // Template Records can be used to decode Data FlowSets to Data Records.
+// Returned pointers refer to records stored in the packet.
 func (p *Packet) TemplateRecords() (list []*TemplateRecord) {
 	for i := range p.FlowSets {
 		switch set := p.FlowSets[i].(type) {
-		case TemplateFlowSet:
+		case *TemplateFlowSet:
 			for j := range set.Records {
 				list = append(list, &set.Records[j])
 			}
@@ -227,11 +229,12 @@ func (p *Packet) TemplateRecords() (list []*TemplateRecord) {
 
 // OptionsTemplateRecords generate a list of all Options Template Records in the
 // packet. Options Template Records can be used to decode Data FlowSets
-// to Options Data Records.
+// to Options Data Records. Returned pointers refer to records stored in the
+// packet.
 func (p *Packet) OptionsTemplateRecords() (list []*OptionsTemplateRecord) {
 	for i := range p.FlowSets {
 		switch set := p.FlowSets[i].(type) {
-		case OptionsTemplateFlowSet:
+		case *OptionsTemplateFlowSet:
 			for j := range set.Records {
 				list = append(list, &set.Records[j])
 			}
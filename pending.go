@@ -0,0 +1,191 @@
+package nf9packet
+
+import (
+	"sync"
+	"time"
+)
+
+// PendingFlowSet is a Data FlowSet waiting in PendingQueue for its template.
+type PendingFlowSet struct {
+	// Buffered Data FlowSet. Data is a private copy.
+	Set DataFlowSet
+
+	// Header of the packet Data FlowSet was received in. FlowSets list of
+	// this packet is always empty.
+	Packet *Packet
+
+	// Time when Data FlowSet was added to the queue.
+	Received time.Time
+}
+
+type pendingList struct {
+	sets  []PendingFlowSet
+	bytes int
+}
+
+// PendingQueue holds Data FlowSets that arrived before their Template Record
+// or Options Template Record. Queue is bounded per exporter, SourceId and
+// Template ID by the number of Data FlowSets, total size of their data and
+// age. PendingQueue is safe for concurrent use by multiple goroutines.
+type PendingQueue struct {
+	// Maximum number of Data FlowSets kept per key. Zero means no limit.
+	MaxCount int
+
+	// Maximum total Data FlowSet data bytes kept per key. Zero means no
+	// limit.
+	MaxBytes int
+
+	// Maximum time Data FlowSet is kept in the queue. Zero means no limit.
+	MaxAge time.Duration
+
+	// OnExpire, if not nil, is called for every Data FlowSet dropped from
+	// the queue undecoded, either because it got too old or because it was
+	// evicted by a newer Data FlowSet to keep the queue within limits.
+	OnExpire func(key TemplateKey, set *PendingFlowSet)
+
+	mu    sync.Mutex
+	lists map[TemplateKey]*pendingList
+	count int
+	now   func() time.Time
+}
+
+// NewPendingQueue creates an empty queue with the given limits. Zero value of
+// any limit means there is no such limit. Limits must not be changed once
+// the queue is in use.
+func NewPendingQueue(maxCount, maxBytes int, maxAge time.Duration) *PendingQueue {
+	return &PendingQueue{
+		MaxCount: maxCount,
+		MaxBytes: maxBytes,
+		MaxAge:   maxAge,
+		lists:    make(map[TemplateKey]*pendingList),
+		now:      time.Now,
+	}
+}
+
+// Len returns the total number of Data FlowSets in the queue.
+func (q *PendingQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.count
+}
+
+// Push adds a copy of Data FlowSet set received in packet p to the queue. The
+// oldest Data FlowSets with the same key are dropped if queue limits are
+// exceeded.
+func (q *PendingQueue) Push(key TemplateKey, p *Packet, set *DataFlowSet) {
+	header := *p
+	header.FlowSets = nil
+
+	pending := PendingFlowSet{
+		Set:      *set,
+		Packet:   &header,
+		Received: q.now(),
+	}
+	pending.Set.Data = append([]byte(nil), set.Data...)
+
+	q.mu.Lock()
+	l, ok := q.lists[key]
+	if !ok {
+		l = &pendingList{}
+		q.lists[key] = l
+	}
+	l.sets = append(l.sets, pending)
+	l.bytes += len(pending.Set.Data)
+	q.count++
+
+	var dropped []PendingFlowSet
+	for len(l.sets) > 0 && q.overLimit(l, pending.Received) {
+		dropped = append(dropped, l.sets[0])
+		l.bytes -= len(l.sets[0].Set.Data)
+		l.sets = l.sets[1:]
+		q.count--
+	}
+	if len(l.sets) == 0 {
+		delete(q.lists, key)
+	}
+	q.mu.Unlock()
+
+	q.expired(key, dropped)
+}
+
+func (q *PendingQueue) overLimit(l *pendingList, now time.Time) bool {
+	switch {
+	case q.MaxCount > 0 && len(l.sets) > q.MaxCount:
+		return true
+	case q.MaxBytes > 0 && l.bytes > q.MaxBytes:
+		return true
+	case q.MaxAge > 0 && now.Sub(l.sets[0].Received) > q.MaxAge:
+		return true
+	default:
+		return false
+	}
+}
+
+// Take removes and returns all Data FlowSets queued under the given key,
+// oldest first. Data FlowSets exceeding MaxAge are dropped instead of being
+// returned.
+func (q *PendingQueue) Take(key TemplateKey) []PendingFlowSet {
+	now := q.now()
+
+	q.mu.Lock()
+	l, ok := q.lists[key]
+	if !ok {
+		q.mu.Unlock()
+		return nil
+	}
+	delete(q.lists, key)
+	q.count -= len(l.sets)
+	q.mu.Unlock()
+
+	var dropped []PendingFlowSet
+	list := l.sets[:0]
+	for _, s := range l.sets {
+		if q.MaxAge > 0 && now.Sub(s.Received) > q.MaxAge {
+			dropped = append(dropped, s)
+		} else {
+			list = append(list, s)
+		}
+	}
+	q.expired(key, dropped)
+
+	if len(list) == 0 {
+		return nil
+	}
+	return list
+}
+
+// Expire drops all Data FlowSets older than MaxAge.
+func (q *PendingQueue) Expire() {
+	if q.MaxAge <= 0 {
+		return
+	}
+	now := q.now()
+
+	dropped := make(map[TemplateKey][]PendingFlowSet)
+	q.mu.Lock()
+	for key, l := range q.lists {
+		for len(l.sets) > 0 && now.Sub(l.sets[0].Received) > q.MaxAge {
+			dropped[key] = append(dropped[key], l.sets[0])
+			l.bytes -= len(l.sets[0].Set.Data)
+			l.sets = l.sets[1:]
+			q.count--
+		}
+		if len(l.sets) == 0 {
+			delete(q.lists, key)
+		}
+	}
+	q.mu.Unlock()
+
+	for key, list := range dropped {
+		q.expired(key, list)
+	}
+}
+
+func (q *PendingQueue) expired(key TemplateKey, list []PendingFlowSet) {
+	if q.OnExpire == nil {
+		return
+	}
+	for i := range list {
+		q.OnExpire(key, &list[i])
+	}
+}
@@ -0,0 +1,99 @@
+package nf9packet
+
+import (
+	"sync"
+)
+
+// Field types of sampled counters and sampling intervals.
+const (
+	fieldInBytes          = 1
+	fieldInPkts           = 2
+	fieldSamplingInterval = 34
+)
+
+// SamplingNormalizer learns sampling configuration of exporters from Options
+// Data Records and scales sampled byte and packet counters of Flow Data
+// Records back to the estimated actual traffic. Sampling configuration is
+// kept per Observation Domain. SamplingNormalizer is safe for concurrent use
+// by multiple goroutines.
+type SamplingNormalizer struct {
+	// Tables samplers are learned into and looked up in. Set it to
+	// Session.Options to use samplers learned by the session. If nil,
+	// empty tables are created on first use. Tables must not be changed
+	// after first use.
+	Tables *OptionsTables
+
+	once sync.Once
+}
+
+// NewSamplingNormalizer creates a normalizer that knows no samplers.
+func NewSamplingNormalizer() *SamplingNormalizer {
+	return &SamplingNormalizer{Tables: NewOptionsTables()}
+}
+
+func (n *SamplingNormalizer) tables() *OptionsTables {
+	n.once.Do(func() {
+		if n.Tables == nil {
+			n.Tables = NewOptionsTables()
+		}
+	})
+	return n.Tables
+}
+
+// LearnOptions learns samplers from Options Data Records received from
+// exporter addr, see OptionsTables.LearnOptions.
+func (n *SamplingNormalizer) LearnOptions(addr string, o *OptionsRecords) {
+	n.tables().LearnOptions(addr, o)
+}
+
+// Learn learns samplers from all Options Data Records of packet sp received
+// from exporter addr.
+func (n *SamplingNormalizer) Learn(addr string, sp *SessionPacket) {
+	n.tables().Learn(addr, sp)
+}
+
+// Sampler returns sampler id learned from Observation Domain key.
+func (n *SamplingNormalizer) Sampler(key DomainKey, id uint64) (Sampler, bool) {
+	return n.tables().Sampler(key, id)
+}
+
+func samplingInterval(v uint32) uint32 {
+	// Zero interval means sampling is disabled.
+	if v == 0 {
+		return 1
+	}
+	return v
+}
+
+// Interval returns sampling interval of Flow Data Record r decoded with
+// template tpl and received from Observation Domain key. Interval of the
+// sampler referred to by FLOW_SAMPLER_ID is preferred if it is known, then
+// SAMPLING_INTERVAL or FLOW_SAMPLER_RANDOM_INTERVAL of the record itself,
+// then the interval learned for the whole Observation Domain. If none of them
+// is known, 1 and false are returned.
+func (n *SamplingNormalizer) Interval(key DomainKey, tpl *TemplateRecord, r *FlowDataRecord) (uint32, bool) {
+	if id := findValue(tpl.Fields, r.Values, fieldFlowSamplerId); id != nil {
+		if s, ok := n.Sampler(key, fieldToUInteger(id)); ok && s.Interval != 0 {
+			return s.Interval, true
+		}
+	}
+	for _, t := range []uint16{fieldSamplingInterval, fieldFlowSamplerRandomInterval} {
+		if v := findValue(tpl.Fields, r.Values, t); v != nil {
+			return samplingInterval(uint32(fieldToUInteger(v))), true
+		}
+	}
+	if v, ok := n.tables().SamplingInterval(key); ok {
+		return v, true
+	}
+	return 1, false
+}
+
+// Normalize returns IN_BYTES and IN_PKTS of Flow Data Record r multiplied by
+// its sampling interval as returned by Interval. Missing counters are
+// reported as zero.
+func (n *SamplingNormalizer) Normalize(key DomainKey, tpl *TemplateRecord, r *FlowDataRecord) (bytes, packets uint64, interval uint32) {
+	interval, _ = n.Interval(key, tpl, r)
+	bytes = fieldToUInteger(findValue(tpl.Fields, r.Values, fieldInBytes)) * uint64(interval)
+	packets = fieldToUInteger(findValue(tpl.Fields, r.Values, fieldInPkts)) * uint64(interval)
+	return bytes, packets, interval
+}
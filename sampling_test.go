@@ -0,0 +1,230 @@
+package nf9packet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSamplerTemplate = OptionsTemplateRecord{
+	TemplateId: 300,
+	Scopes:     []Field{{Type: 1, Length: 4}}, // System
+	Options: []Field{
+		{Type: 48, Length: 1},  // FLOW_SAMPLER_ID
+		{Type: 49, Length: 1},  // FLOW_SAMPLER_MODE
+		{Type: 50, Length: 4},  // FLOW_SAMPLER_RANDOM_INTERVAL
+		{Type: 84, Length: 16}, // SAMPLER_NAME
+	},
+}
+
+var testSampledTemplate = TemplateRecord{
+	TemplateId: 256,
+	Fields: []Field{
+		{Type: 48, Length: 1}, // FLOW_SAMPLER_ID
+		{Type: 1, Length: 4},  // IN_BYTES
+		{Type: 2, Length: 4},  // IN_PKTS
+	},
+}
+
+func testSamplerRecord(id byte, interval uint32, name string) OptionsDataRecord {
+	return OptionsDataRecord{
+		[][]byte{{0, 0, 0, 1}},
+		[][]byte{{id}, {2}, {byte(interval >> 24), byte(interval >> 16), byte(interval >> 8), byte(interval)}, append([]byte(name), make([]byte, 16-len(name))...)},
+	}
+}
+
+func TestSamplingNormalizer(t *testing.T) {
+	var w packetRecorder
+	e := NewExporter(&w, 1)
+	e.AddOptionsTemplate(testSamplerTemplate)
+	e.AddTemplate(testSampledTemplate)
+	require.NoError(t, e.WriteOptionsRecords(300, testSamplerRecord(1, 1000, "sampler-1k"), testSamplerRecord(2, 100, "")))
+	require.NoError(t, e.WriteRecords(256,
+		FlowDataRecord{[][]byte{{1}, {0, 0, 0x05, 0xdc}, {0, 0, 0, 1}}},
+		FlowDataRecord{[][]byte{{2}, {0, 0, 0, 0x40}, {0, 0, 0, 2}}},
+		FlowDataRecord{[][]byte{{3}, {0, 0, 0, 0x40}, {0, 0, 0, 2}}},
+	))
+	require.NoError(t, e.Flush())
+
+	s := NewSession()
+	s.Sampling = NewSamplingNormalizer()
+	var flows []FlowRecords
+	for _, data := range w.packets {
+		p, err := s.Decode("exporter", data)
+		require.NoError(t, err)
+		flows = append(flows, p.Flows...)
+	}
+	require.Len(t, flows, 1)
+	require.Len(t, flows[0].Records, 3)
+
+	key := DomainKey{"exporter", 1}
+	sampler, ok := s.Sampling.Sampler(key, 1)
+	require.True(t, ok)
+	assert.Equal(t, Sampler{Scope: Scope{ScopeSystem, 1}, Id: 1, Mode: 2, Interval: 1000, Name: "sampler-1k"}, sampler)
+
+	tpl := flows[0].Template
+	bytes, packets, interval := s.Sampling.Normalize(key, tpl, &flows[0].Records[0])
+	assert.Equal(t, uint64(1500000), bytes)
+	assert.Equal(t, uint64(1000), packets)
+	assert.Equal(t, uint32(1000), interval)
+
+	bytes, packets, interval = s.Sampling.Normalize(key, tpl, &flows[0].Records[1])
+	assert.Equal(t, uint64(6400), bytes)
+	assert.Equal(t, uint64(200), packets)
+	assert.Equal(t, uint32(100), interval)
+
+	// Unknown sampler.
+	bytes, packets, interval = s.Sampling.Normalize(key, tpl, &flows[0].Records[2])
+	assert.Equal(t, uint64(64), bytes)
+	assert.Equal(t, uint64(2), packets)
+	assert.Equal(t, uint32(1), interval)
+
+	// Samplers are not shared between Observation Domains.
+	_, ok = s.Sampling.Interval(DomainKey{"exporter", 2}, tpl, &flows[0].Records[0])
+	assert.False(t, ok)
+}
+
+func TestSamplingNormalizerDomainInterval(t *testing.T) {
+	n := NewSamplingNormalizer()
+	n.LearnOptions("exporter", &OptionsRecords{
+		Packet: &Packet{Version: 9, SourceId: 1},
+		Template: &OptionsTemplateRecord{
+			TemplateId: 300,
+			Scopes:     []Field{{Type: 1, Length: 4}},
+			Options:    []Field{{Type: 34, Length: 4}, {Type: 35, Length: 1}}, // SAMPLING_INTERVAL, SAMPLING_ALGORITHM
+		},
+		Records: []OptionsDataRecord{{[][]byte{{0, 0, 0, 0}}, [][]byte{{0, 0, 0, 10}, {1}}}},
+	})
+
+	tpl := &TemplateRecord{256, 1, []Field{{Type: 1, Length: 4}}}
+	bytes, _, interval := n.Normalize(DomainKey{"exporter", 1}, tpl, &FlowDataRecord{[][]byte{{0, 0, 0, 100}}})
+	assert.Equal(t, uint64(1000), bytes)
+	assert.Equal(t, uint32(10), interval)
+}
+
+func TestSamplingNormalizerV5(t *testing.T) {
+	s := NewSession()
+	s.Sampling = NewSamplingNormalizer()
+	p, err := s.Decode("exporter", testV5Packet)
+	require.NoError(t, err)
+	require.Len(t, p.Flows, 1)
+
+	bytes, packets, interval := s.Sampling.Normalize(DomainKey{"exporter", p.SourceId}, p.Flows[0].Template, &p.Flows[0].Records[0])
+	assert.Equal(t, uint64(409600), bytes)
+	assert.Equal(t, uint64(1000), packets)
+	assert.Equal(t, uint32(100), interval)
+}
+
+func TestSamplingNormalizerSharedTables(t *testing.T) {
+	var w packetRecorder
+	e := NewExporter(&w, 1)
+	e.AddOptionsTemplate(testSamplerTemplate)
+	require.NoError(t, e.WriteOptionsRecords(300, testSamplerRecord(1, 1000, "")))
+	require.NoError(t, e.Flush())
+
+	s := NewSession()
+	s.Options = NewOptionsTables()
+	s.Sampling = &SamplingNormalizer{Tables: s.Options}
+	for _, data := range w.packets {
+		_, err := s.Decode("exporter", data)
+		require.NoError(t, err)
+	}
+
+	sampler, ok := s.Sampling.Sampler(DomainKey{"exporter", 1}, 1)
+	require.True(t, ok)
+	assert.Equal(t, uint32(1000), sampler.Interval)
+	assert.Equal(t, []Sampler{sampler}, s.Options.Samplers(DomainKey{"exporter", 1}))
+}
+
+func TestSamplingNormalizerZeroValue(t *testing.T) {
+	var n SamplingNormalizer
+	tpl := &TemplateRecord{256, 1, []Field{{Type: 1, Length: 4}}}
+	r := &FlowDataRecord{[][]byte{{0, 0, 0, 100}}}
+	bytes, _, interval := n.Normalize(DomainKey{"exporter", 1}, tpl, r)
+	assert.Equal(t, uint64(100), bytes)
+	assert.Equal(t, uint32(1), interval)
+
+	n.LearnOptions("exporter", &OptionsRecords{
+		Packet:   &Packet{Version: 9, SourceId: 1},
+		Template: &testSamplerTemplate,
+		Records:  []OptionsDataRecord{testSamplerRecord(1, 10, "")},
+	})
+	_, ok := n.Sampler(DomainKey{"exporter", 1}, 1)
+	assert.True(t, ok)
+}
+
+func TestSamplingNormalizerEnterpriseFields(t *testing.T) {
+	n := NewSamplingNormalizer()
+	n.LearnOptions("exporter", &OptionsRecords{
+		Packet:   &Packet{Version: 9, SourceId: 1},
+		Template: &testSamplerTemplate,
+		Records:  []OptionsDataRecord{testSamplerRecord(1, 10, "")},
+	})
+
+	// Enterprise-specific fields with colliding types come first.
+	tpl := &TemplateRecord{256, 6, []Field{
+		{Type: 48, Length: 1, EnterpriseNumber: 9},
+		{Type: 1, Length: 4, EnterpriseNumber: 9},
+		{Type: 2, Length: 4, EnterpriseNumber: 9},
+		{Type: 48, Length: 1},
+		{Type: 1, Length: 4},
+		{Type: 2, Length: 4},
+	}}
+	r := &FlowDataRecord{[][]byte{{2}, {0, 0, 0, 0xff}, {0, 0, 0, 0xff}, {1}, {0, 0, 0, 100}, {0, 0, 0, 1}}}
+	bytes, packets, interval := n.Normalize(DomainKey{"exporter", 1}, tpl, r)
+	assert.Equal(t, uint64(1000), bytes)
+	assert.Equal(t, uint64(10), packets)
+	assert.Equal(t, uint32(10), interval)
+
+	// Only enterprise-specific fields, nothing to normalize.
+	bytes, packets, interval = n.Normalize(DomainKey{"exporter", 1}, &TemplateRecord{256, 3, tpl.Fields[:3]}, r)
+	assert.Zero(t, bytes)
+	assert.Zero(t, packets)
+	assert.Equal(t, uint32(1), interval)
+}
+
+func TestSamplingNormalizerSamplerInterval(t *testing.T) {
+	n := NewSamplingNormalizer()
+	key := DomainKey{"exporter", 1}
+	tpl := &TemplateRecord{256, 2, []Field{{Type: 48, Length: 1}, {Type: 1, Length: 4}}}
+	r := &FlowDataRecord{[][]byte{{1}, {0, 0, 0, 100}}}
+
+	// Sampler announced with SAMPLING_INTERVAL instead of
+	// FLOW_SAMPLER_RANDOM_INTERVAL.
+	n.LearnOptions("exporter", &OptionsRecords{
+		Packet: &Packet{Version: 9, SourceId: 1},
+		Template: &OptionsTemplateRecord{
+			TemplateId: 300,
+			Scopes:     []Field{{Type: 1, Length: 4}},
+			Options:    []Field{{Type: 48, Length: 1}, {Type: 34, Length: 4}},
+		},
+		Records: []OptionsDataRecord{{[][]byte{{0, 0, 0, 1}}, [][]byte{{1}, {0, 0, 0x03, 0xe8}}}},
+	})
+	bytes, _, interval := n.Normalize(key, tpl, r)
+	assert.Equal(t, uint64(100000), bytes)
+	assert.Equal(t, uint32(1000), interval)
+
+	// Sampler without interval falls back to the domain interval.
+	n.LearnOptions("exporter", &OptionsRecords{
+		Packet: &Packet{Version: 9, SourceId: 1},
+		Template: &OptionsTemplateRecord{
+			TemplateId: 301,
+			Scopes:     []Field{{Type: 1, Length: 4}},
+			Options:    []Field{{Type: 48, Length: 1}, {Type: 49, Length: 1}},
+		},
+		Records: []OptionsDataRecord{{[][]byte{{0, 0, 0, 1}}, [][]byte{{1}, {2}}}},
+	})
+	n.LearnOptions("exporter", &OptionsRecords{
+		Packet: &Packet{Version: 9, SourceId: 1},
+		Template: &OptionsTemplateRecord{
+			TemplateId: 302,
+			Scopes:     []Field{{Type: 1, Length: 4}},
+			Options:    []Field{{Type: 34, Length: 4}},
+		},
+		Records: []OptionsDataRecord{{[][]byte{{0, 0, 0, 1}}, [][]byte{{0, 0, 0, 10}}}},
+	})
+	bytes, _, interval = n.Normalize(key, tpl, r)
+	assert.Equal(t, uint64(1000), bytes)
+	assert.Equal(t, uint32(10), interval)
+}
@@ -0,0 +1,272 @@
+package nf9packet
+
+import (
+	"sync"
+)
+
+// DomainKey identifies an Observation Domain of a particular exporter.
+type DomainKey struct {
+	// Exporter address, usually string representation of the UDP source
+	// address the packet was received from.
+	Addr string
+
+	// Exporter Observation Domain (Packet.SourceId).
+	SourceId uint32
+}
+
+// SequenceStatus describes how the SequenceNumber of a packet relates to the
+// packets received before it.
+type SequenceStatus int
+
+const (
+	// SequenceNumber is the expected one.
+	SequenceInOrder SequenceStatus = iota
+
+	// First packet of the Observation Domain.
+	SequenceFirst
+
+	// Some packets between the previous and this one are missing.
+	SequenceGap
+
+	// Packet with the same SequenceNumber was already received.
+	SequenceDuplicate
+
+	// Packet arrived after a packet sent later, it fills an earlier gap.
+	SequenceReordered
+
+	// Exporter restarted, sequence counting starts over.
+	SequenceReset
+)
+
+// String returns a short name of sequence status.
+func (s SequenceStatus) String() string {
+	switch s {
+	case SequenceInOrder:
+		return "in order"
+	case SequenceFirst:
+		return "first"
+	case SequenceGap:
+		return "gap"
+	case SequenceDuplicate:
+		return "duplicate"
+	case SequenceReordered:
+		return "reordered"
+	case SequenceReset:
+		return "reset"
+	default:
+		return "unknown"
+	}
+}
+
+// SequenceStats holds sequence counters of an Observation Domain.
+type SequenceStats struct {
+	// Number of packets tracked.
+	Packets uint64
+
+	// Estimated number of lost packets (NetFlow v9) or Data Records
+	// (NetFlow v5, v7 and IPFIX), i.e. sequence numbers skipped in gaps and
+	// not filled by reordered packets later.
+	Lost uint64
+
+	// Number of duplicate packets.
+	Duplicates uint64
+
+	// Number of packets that arrived out of order.
+	Reordered uint64
+
+	// Number of exporter restarts.
+	Resets uint64
+}
+
+func (s *SequenceStats) add(o SequenceStats) {
+	s.Packets += o.Packets
+	s.Lost += o.Lost
+	s.Duplicates += o.Duplicates
+	s.Reordered += o.Reordered
+	s.Resets += o.Resets
+}
+
+// Number of recently received sequence numbers remembered per domain to
+// tell duplicates from reordered packets.
+const sequenceHistory = 64
+
+type sequenceEntry struct {
+	sequence  uint32
+	sysUpTime uint32
+}
+
+type sequenceState struct {
+	stats     SequenceStats
+	next      uint32
+	sysUpTime uint32
+	// Record count of the last packet was not known, so next only holds
+	// its SequenceNumber.
+	resync    bool
+	recent    [sequenceHistory]sequenceEntry
+	recentLen int
+	recentPos int
+}
+
+// seen reports whether packet p was already received: a remembered packet
+// has the same SequenceNumber and SysUpTime. Packets of a restarted exporter
+// reusing a remembered SequenceNumber are not duplicates.
+func (st *sequenceState) seen(p *Packet) bool {
+	for i := 0; i < st.recentLen; i++ {
+		if st.recent[i].sequence == p.SequenceNumber && st.recent[i].sysUpTime == p.SysUpTime {
+			return true
+		}
+	}
+	return false
+}
+
+// consistent reports whether packet p sent before the last one fits among
+// remembered packets: the closest remembered packet sent before p must not
+// have a larger SysUpTime. Packets of a restarted exporter do not fit.
+func (st *sequenceState) consistent(p *Packet) bool {
+	var closest *sequenceEntry
+	for i := 0; i < st.recentLen; i++ {
+		e := &st.recent[i]
+		if diff := int32(p.SequenceNumber - e.sequence); diff > 0 && (closest == nil || diff < int32(p.SequenceNumber-closest.sequence)) {
+			closest = e
+		}
+	}
+	return closest != nil && int32(p.SysUpTime-closest.sysUpTime) >= 0
+}
+
+func (st *sequenceState) remember(p *Packet) {
+	st.recent[st.recentPos] = sequenceEntry{p.SequenceNumber, p.SysUpTime}
+	st.recentPos = (st.recentPos + 1) % sequenceHistory
+	if st.recentLen < sequenceHistory {
+		st.recentLen++
+	}
+}
+
+func (st *sequenceState) advance(p *Packet, increment uint32, known bool) {
+	st.next = p.SequenceNumber + increment
+	st.resync = !known
+	st.sysUpTime = p.SysUpTime
+	st.remember(p)
+}
+
+func (st *sequenceState) reset(p *Packet, increment uint32, known bool) {
+	st.recentLen, st.recentPos = 0, 0
+	st.advance(p, increment, known)
+}
+
+// SequenceTracker follows packet SequenceNumber of every Observation Domain
+// and detects lost, duplicate and reordered packets and exporter restarts.
+// NetFlow v9 counts packets, while NetFlow v5, v7 and IPFIX count Data
+// Records, so Lost is measured in different units depending on the protocol.
+// SequenceTracker is safe for concurrent use by multiple goroutines, but
+// packets of a single domain must be tracked in order of arrival.
+type SequenceTracker struct {
+	// Packets with SequenceNumber at most this far behind the expected one
+	// are treated as reordered or duplicate. Larger backward jumps are
+	// treated as exporter restarts.
+	MaxReorder uint32
+
+	mu      sync.Mutex
+	domains map[DomainKey]*sequenceState
+}
+
+// NewSequenceTracker creates an empty tracker.
+func NewSequenceTracker() *SequenceTracker {
+	return &SequenceTracker{
+		MaxReorder: 1024,
+		domains:    make(map[DomainKey]*sequenceState),
+	}
+}
+
+// Track checks SequenceNumber of packet p received from exporter addr.
+// Records is the number of Data Records in the packet, it is used for NetFlow
+// v5, v7 and IPFIX whose sequence numbers count records instead of packets.
+// For NetFlow v5 and v7 it can be taken from Packet.Count. Negative records
+// means the number is not known, e.g. because some Data Sets could not be
+// decoded yet; the next packet sent after p is then accepted without counting
+// lost records.
+func (t *SequenceTracker) Track(addr string, p *Packet, records int) SequenceStatus {
+	increment, known := uint32(1), true
+	if p.Version != 9 {
+		increment, known = uint32(records), records >= 0
+		if !known {
+			increment = 0
+		}
+	}
+	// IPFIX has no SysUpTime, so restarts are only detected by large
+	// backward jumps of SequenceNumber.
+	hasUpTime := p.Version != 10
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := DomainKey{addr, p.SourceId}
+	st, ok := t.domains[key]
+	if !ok {
+		st = &sequenceState{}
+		t.domains[key] = st
+		st.reset(p, increment, known)
+		st.stats.Packets++
+		return SequenceFirst
+	}
+	st.stats.Packets++
+
+	diff := int32(p.SequenceNumber - st.next)
+	// After a packet with unknown record count next is the SequenceNumber
+	// of that packet, so packets sent later are ahead of it.
+	behind := (diff < 0 || diff == 0 && st.resync) && uint32(-diff) <= t.MaxReorder
+	if behind && hasUpTime && !st.seen(p) {
+		behind = st.consistent(p)
+	}
+	// SysUpTime wraps around after 49.7 days, so it is compared the same
+	// way as by Packet.Time.
+	restarted := hasUpTime && int32(p.SysUpTime-st.sysUpTime) < 0
+
+	switch {
+	case diff == 0 && !st.resync, diff > 0 && !restarted && st.resync:
+		st.advance(p, increment, known)
+		return SequenceInOrder
+	case behind && st.seen(p):
+		st.stats.Duplicates++
+		return SequenceDuplicate
+	case behind:
+		st.stats.Reordered++
+		if st.stats.Lost >= uint64(increment) {
+			st.stats.Lost -= uint64(increment)
+		} else {
+			st.stats.Lost = 0
+		}
+		st.remember(p)
+		return SequenceReordered
+	case diff > 0 && !restarted:
+		st.stats.Lost += uint64(diff)
+		st.advance(p, increment, known)
+		return SequenceGap
+	default:
+		st.stats.Resets++
+		st.reset(p, increment, known)
+		return SequenceReset
+	}
+}
+
+// Stats returns counters of a single Observation Domain.
+func (t *SequenceTracker) Stats(key DomainKey) SequenceStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if st, ok := t.domains[key]; ok {
+		return st.stats
+	}
+	return SequenceStats{}
+}
+
+// Total returns counters of all Observation Domains combined.
+func (t *SequenceTracker) Total() SequenceStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var total SequenceStats
+	for _, st := range t.domains {
+		total.add(st.stats)
+	}
+	return total
+}
@@ -0,0 +1,146 @@
+package nf9packet
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequenceTracker(t *testing.T) {
+	tracker := NewSequenceTracker()
+	packet := func(sequence, upTime uint32) *Packet {
+		return &Packet{Version: 9, SequenceNumber: sequence, SysUpTime: upTime, SourceId: 1}
+	}
+
+	tests := []struct {
+		sequence uint32
+		upTime   uint32
+		status   SequenceStatus
+	}{
+		{10, 1000, SequenceFirst},
+		{11, 1100, SequenceInOrder},
+		{14, 1400, SequenceGap},       // 12 and 13 missing
+		{12, 1200, SequenceReordered}, // 13 still missing
+		{12, 1200, SequenceDuplicate},
+		{15, 1500, SequenceInOrder},
+		{0, 50, SequenceReset},
+		{1, 150, SequenceInOrder},
+		{3, 350, SequenceGap},
+		{0xffffffff, 500, SequenceReset}, // Older than every packet seen since reset
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.status, tracker.Track("192.0.2.1", packet(test.sequence, test.upTime), 0), "sequence %d", test.sequence)
+	}
+
+	stats := tracker.Stats(DomainKey{"192.0.2.1", 1})
+	assert.Equal(t, SequenceStats{Packets: 10, Lost: 2, Duplicates: 1, Reordered: 1, Resets: 2}, stats)
+	assert.Equal(t, stats, tracker.Total())
+	assert.Equal(t, SequenceStats{}, tracker.Stats(DomainKey{"192.0.2.1", 2}))
+}
+
+func TestSequenceTrackerRestart(t *testing.T) {
+	tracker := NewSequenceTracker()
+	packet := func(sequence, upTime uint32) *Packet {
+		return &Packet{Version: 9, SequenceNumber: sequence, SysUpTime: upTime}
+	}
+
+	for i := uint32(0); i < 5; i++ {
+		tracker.Track("a", packet(i, 100000+i*100), 0)
+	}
+	// Restarted exporter reuses a remembered SequenceNumber.
+	assert.Equal(t, SequenceReset, tracker.Track("a", packet(1, 50), 0))
+	assert.Equal(t, SequenceInOrder, tracker.Track("a", packet(2, 150), 0))
+	assert.Equal(t, SequenceStats{Packets: 7, Resets: 1}, tracker.Stats(DomainKey{"a", 0}))
+
+	// SysUpTime wraps around, packet 11 is lost.
+	assert.Equal(t, SequenceFirst, tracker.Track("b", packet(10, 0xffffff00), 0))
+	assert.Equal(t, SequenceGap, tracker.Track("b", packet(12, 0x100), 0))
+	assert.Equal(t, SequenceReordered, tracker.Track("b", packet(11, 0xffffff80), 0))
+	assert.Equal(t, SequenceDuplicate, tracker.Track("b", packet(11, 0xffffff80), 0))
+	assert.Equal(t, SequenceStats{Packets: 4, Reordered: 1, Duplicates: 1}, tracker.Stats(DomainKey{"b", 0}))
+}
+
+func TestSequenceTrackerRecords(t *testing.T) {
+	tracker := NewSequenceTracker()
+
+	// IPFIX sequence counts Data Records.
+	p := &Packet{Version: 10, SequenceNumber: 100}
+	assert.Equal(t, SequenceFirst, tracker.Track("a", p, 5))
+	p.SequenceNumber = 105
+	assert.Equal(t, SequenceInOrder, tracker.Track("a", p, 5))
+	p.SequenceNumber = 120
+	assert.Equal(t, SequenceGap, tracker.Track("a", p, 5))
+	assert.Equal(t, uint64(10), tracker.Stats(DomainKey{"a", 0}).Lost)
+
+	// Domains are independent.
+	p = &Packet{Version: 5, SequenceNumber: 0, Count: 2}
+	assert.Equal(t, SequenceFirst, tracker.Track("b", p, int(p.Count)))
+	p.SequenceNumber = 2
+	assert.Equal(t, SequenceInOrder, tracker.Track("b", p, int(p.Count)))
+}
+
+func TestSessionSequence(t *testing.T) {
+	s := NewSession()
+	s.Sequences = NewSequenceTracker()
+
+	sp, err := s.Decode("192.0.2.1:2055", testTemplatePacket)
+	require.NoError(t, err)
+	assert.Equal(t, SequenceFirst, sp.Sequence)
+
+	sp, err = s.Decode("192.0.2.1:2055", testDataPacket)
+	require.NoError(t, err)
+	assert.Equal(t, SequenceInOrder, sp.Sequence)
+}
+
+func TestSequenceTrackerUnknownRecords(t *testing.T) {
+	tracker := NewSequenceTracker()
+
+	p := &Packet{Version: 10, SequenceNumber: 100}
+	assert.Equal(t, SequenceFirst, tracker.Track("a", p, -1))
+	assert.Equal(t, SequenceDuplicate, tracker.Track("a", p, -1))
+	p.SequenceNumber = 103
+	assert.Equal(t, SequenceInOrder, tracker.Track("a", p, 2))
+	p.SequenceNumber = 105
+	assert.Equal(t, SequenceInOrder, tracker.Track("a", p, 2))
+	p.SequenceNumber = 110
+	assert.Equal(t, SequenceGap, tracker.Track("a", p, 2))
+	assert.Equal(t, SequenceStats{Packets: 5, Lost: 3, Duplicates: 1}, tracker.Stats(DomainKey{"a", 0}))
+}
+
+func TestSessionSequenceRestart(t *testing.T) {
+	message := func(sequence uint32, sets ...[]byte) []byte {
+		data := append([]byte(nil), testIPFIXMessage[:16]...)
+		for _, set := range sets {
+			data = append(data, set...)
+		}
+		binary.BigEndian.PutUint16(data[2:], uint16(len(data)))
+		binary.BigEndian.PutUint32(data[8:], sequence)
+		return data
+	}
+	template, dataSet := testIPFIXMessage[16:40], testIPFIXMessage[60:]
+
+	// Collector restarted, data arrives before its template.
+	s := NewSession()
+	s.Sequences = NewSequenceTracker()
+	sp, err := s.Decode("192.0.2.1:4739", message(1000, dataSet))
+	require.NoError(t, err)
+	assert.Len(t, sp.Unknown, 1)
+	assert.Equal(t, SequenceFirst, sp.Sequence)
+
+	sp, err = s.Decode("192.0.2.1:4739", message(1002, dataSet))
+	require.NoError(t, err)
+	assert.Len(t, sp.Unknown, 1)
+	assert.Equal(t, SequenceInOrder, sp.Sequence)
+
+	sp, err = s.Decode("192.0.2.1:4739", message(1004, template, dataSet))
+	require.NoError(t, err)
+	assert.Empty(t, sp.Unknown)
+	assert.Equal(t, SequenceInOrder, sp.Sequence)
+
+	sp, err = s.Decode("192.0.2.1:4739", message(1006, dataSet))
+	require.NoError(t, err)
+	assert.Equal(t, SequenceInOrder, sp.Sequence)
+	assert.Equal(t, SequenceStats{Packets: 4}, s.Sequences.Stats(DomainKey{"192.0.2.1:4739", 7}))
+}
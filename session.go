@@ -0,0 +1,515 @@
+package nf9packet
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TemplateKey identifies a single Template Record or Options Template Record
+// received from a particular exporter. Template IDs are unique only within
+// the Observation Domain that generated them, so exporter address and
+// SourceId are part of the key.
+type TemplateKey struct {
+	// Exporter address, usually string representation of the UDP source
+	// address the packet was received from.
+	Addr string
+
+	// Exporter Observation Domain (Packet.SourceId).
+	SourceId uint32
+
+	// Template ID of the Template Record or Options Template Record.
+	TemplateId uint16
+}
+
+// TemplateEventType describes what happened to a cached template.
+type TemplateEventType int
+
+const (
+	// Template was seen for the first time.
+	TemplateAdded TemplateEventType = iota
+
+	// Identical template was received again.
+	TemplateRefreshed
+
+	// Template ID was reused for a template with a different field list.
+	TemplateChanged
+
+	// Template was not refreshed within TemplateCache.Timeout.
+	TemplateExpired
+
+	// Exporter withdrew the template by sending a template with no fields.
+	TemplateWithdrawn
+)
+
+// String returns a short name of template event type.
+func (t TemplateEventType) String() string {
+	switch t {
+	case TemplateAdded:
+		return "added"
+	case TemplateRefreshed:
+		return "refreshed"
+	case TemplateChanged:
+		return "changed"
+	case TemplateExpired:
+		return "expired"
+	case TemplateWithdrawn:
+		return "withdrawn"
+	default:
+		return "unknown"
+	}
+}
+
+// TemplateEvent is reported by TemplateCache when a cached template is added,
+// refreshed, changed, expired or withdrawn. At most one of Template and
+// OptionsTemplate is set, same applies to the Previous pair.
+type TemplateEvent struct {
+	Type TemplateEventType
+	Key  TemplateKey
+
+	// Template now stored in the cache. Both are nil for expired and
+	// withdrawn templates.
+	Template        *TemplateRecord
+	OptionsTemplate *OptionsTemplateRecord
+
+	// Template stored in the cache before the event. Both are nil for added
+	// templates.
+	PreviousTemplate        *TemplateRecord
+	PreviousOptionsTemplate *OptionsTemplateRecord
+}
+
+type templateEntry struct {
+	template *TemplateRecord
+	options  *OptionsTemplateRecord
+	seen     time.Time
+}
+
+// TemplateCache keeps track of Template Records and Options Template Records
+// seen from multiple exporters. It is safe for concurrent use by multiple
+// goroutines. Cached templates are private copies and must not be modified.
+type TemplateCache struct {
+	// Templates not refreshed for longer than Timeout are considered
+	// expired. Zero means templates never expire.
+	Timeout time.Duration
+
+	// OnEvent, if not nil, is called for every template event. It is called
+	// without holding any cache locks, so it may call cache methods.
+	OnEvent func(ev TemplateEvent)
+
+	mu      sync.RWMutex
+	entries map[TemplateKey]*templateEntry
+	now     func() time.Time
+}
+
+// NewTemplateCache creates an empty template cache. Timeout and OnEvent must
+// be set before the cache is used.
+func NewTemplateCache() *TemplateCache {
+	return &TemplateCache{
+		entries: make(map[TemplateKey]*templateEntry),
+		now:     time.Now,
+	}
+}
+
+func copyFields(fields []Field) []Field {
+	if fields == nil {
+		return nil
+	}
+	return append([]Field(nil), fields...)
+}
+
+func fieldsEqual(a, b []Field) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *templateEntry) equal(n *templateEntry) bool {
+	switch {
+	case e.template != nil && n.template != nil:
+		return fieldsEqual(e.template.Fields, n.template.Fields)
+	case e.options != nil && n.options != nil:
+		return fieldsEqual(e.options.Scopes, n.options.Scopes) &&
+			fieldsEqual(e.options.Options, n.options.Options)
+	default:
+		return false
+	}
+}
+
+func (c *TemplateCache) store(key TemplateKey, n *templateEntry, withdraw bool) {
+	ev := TemplateEvent{Key: key}
+
+	var expired *TemplateEvent
+
+	c.mu.Lock()
+	old, ok := c.entries[key]
+	if ok && c.expired(old, n.seen) {
+		expired = &TemplateEvent{
+			Type:                    TemplateExpired,
+			Key:                     key,
+			PreviousTemplate:        old.template,
+			PreviousOptionsTemplate: old.options,
+		}
+		delete(c.entries, key)
+		ok = false
+	}
+	switch {
+	case withdraw:
+		if !ok {
+			c.mu.Unlock()
+			if expired != nil {
+				c.event(*expired)
+			}
+			return
+		}
+		delete(c.entries, key)
+		ev.Type = TemplateWithdrawn
+	case !ok:
+		c.entries[key] = n
+		ev.Type = TemplateAdded
+	case old.equal(n):
+		// Keep the old copy, so pointers handed out earlier stay
+		// current.
+		old.seen = n.seen
+		n = old
+		ev.Type = TemplateRefreshed
+	default:
+		c.entries[key] = n
+		ev.Type = TemplateChanged
+	}
+	c.mu.Unlock()
+
+	if expired != nil {
+		c.event(*expired)
+	}
+	if !withdraw {
+		ev.Template, ev.OptionsTemplate = n.template, n.options
+	}
+	if ok {
+		ev.PreviousTemplate, ev.PreviousOptionsTemplate = old.template, old.options
+	}
+	c.event(ev)
+}
+
+func (c *TemplateCache) expired(e *templateEntry, now time.Time) bool {
+	return c.Timeout > 0 && now.Sub(e.seen) > c.Timeout
+}
+
+func (c *TemplateCache) event(ev TemplateEvent) {
+	if c.OnEvent != nil {
+		c.OnEvent(ev)
+	}
+}
+
+// AddTemplate stores a copy of Template Record t under the given key. Any
+// Options Template Record previously stored with the same key is replaced as
+// Template IDs are shared by both template kinds. Template Record without
+// fields withdraws the template.
+func (c *TemplateCache) AddTemplate(key TemplateKey, t *TemplateRecord) {
+	tpl := *t
+	tpl.Fields = copyFields(t.Fields)
+
+	c.store(key, &templateEntry{template: &tpl, seen: c.now()}, len(tpl.Fields) == 0)
+}
+
+// AddOptionsTemplate stores a copy of Options Template Record t under the
+// given key. Any Template Record previously stored with the same key is
+// replaced as Template IDs are shared by both template kinds. Options Template
+// Record without scope and option fields withdraws the template.
+func (c *TemplateCache) AddOptionsTemplate(key TemplateKey, t *OptionsTemplateRecord) {
+	tpl := *t
+	tpl.Scopes = copyFields(t.Scopes)
+	tpl.Options = copyFields(t.Options)
+
+	withdraw := len(tpl.Scopes) == 0 && len(tpl.Options) == 0
+	c.store(key, &templateEntry{options: &tpl, seen: c.now()}, withdraw)
+}
+
+func (c *TemplateCache) lookup(key TemplateKey) *templateEntry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.entries[key]
+	if !ok || c.expired(e, c.now()) {
+		return nil
+	}
+	return e
+}
+
+// Template returns Template Record stored under the given key or nil if there
+// is no such template or it has expired.
+func (c *TemplateCache) Template(key TemplateKey) *TemplateRecord {
+	if e := c.lookup(key); e != nil {
+		return e.template
+	}
+	return nil
+}
+
+// OptionsTemplate returns Options Template Record stored under the given key
+// or nil if there is no such template or it has expired.
+func (c *TemplateCache) OptionsTemplate(key TemplateKey) *OptionsTemplateRecord {
+	if e := c.lookup(key); e != nil {
+		return e.options
+	}
+	return nil
+}
+
+// Expire removes all templates not refreshed within Timeout and reports
+// TemplateExpired event for each of them.
+func (c *TemplateCache) Expire() {
+	if c.Timeout <= 0 {
+		return
+	}
+	now := c.now()
+
+	var events []TemplateEvent
+	c.mu.Lock()
+	for key, e := range c.entries {
+		if c.expired(e, now) {
+			delete(c.entries, key)
+			events = append(events, TemplateEvent{
+				Type:                    TemplateExpired,
+				Key:                     key,
+				PreviousTemplate:        e.template,
+				PreviousOptionsTemplate: e.options,
+			})
+		}
+	}
+	c.mu.Unlock()
+
+	for _, ev := range events {
+		c.event(ev)
+	}
+}
+
+// Learn stores all Template Records and Options Template Records found in
+// packet p received from exporter addr.
+func (c *TemplateCache) Learn(addr string, p *Packet) {
+	for _, t := range p.TemplateRecords() {
+		c.AddTemplate(TemplateKey{addr, p.SourceId, t.TemplateId}, t)
+	}
+	for _, t := range p.OptionsTemplateRecords() {
+		c.AddOptionsTemplate(TemplateKey{addr, p.SourceId, t.TemplateId}, t)
+	}
+}
+
+// FlowRecords is a list of Flow Data Records decoded from a single Data
+// FlowSet together with the Template Record used for decoding.
+type FlowRecords struct {
+	// Packet the Data FlowSet was received in. For Data FlowSets replayed
+	// from PendingQueue this is the header of an earlier packet.
+	Packet *Packet
+
+	Template *TemplateRecord
+	Records  []FlowDataRecord
+}
+
+// OptionsRecords is a list of Options Data Records decoded from a single Data
+// FlowSet together with the Options Template Record used for decoding.
+type OptionsRecords struct {
+	// Packet the Data FlowSet was received in. For Data FlowSets replayed
+	// from PendingQueue this is the header of an earlier packet.
+	Packet *Packet
+
+	Template *OptionsTemplateRecord
+	Records  []OptionsDataRecord
+}
+
+// SessionPacket is a packet decoded in a Session context. In addition to the
+// Packet itself it contains all Data FlowSets decoded using known templates.
+type SessionPacket struct {
+	*Packet
+
+	// Flow Data Records grouped by Data FlowSet.
+	Flows []FlowRecords
+
+	// Options Data Records grouped by Data FlowSet.
+	Options []OptionsRecords
+
+	// Data FlowSets that could not be decoded because matching template
+	// is not known yet. If session has a PendingQueue these Data FlowSets
+	// are also queued and will be decoded once the template arrives.
+	Unknown []*DataFlowSet
+
+	// Problems found while decoding Data FlowSets. Complete records before
+	// the problem are still reported in Flows or Options.
+	Errors []error
+
+	// SequenceNumber status of the packet if session has a
+	// SequenceTracker.
+	Sequence SequenceStatus
+}
+
+// Session decodes NetFlow v9 (and IPFIX) packets keeping track of templates
+// announced by exporters. Template Records and Options Template Records are learned
+// automatically and Data FlowSets are decoded as soon as their template is
+// known. Session is safe for concurrent use by multiple goroutines.
+type Session struct {
+	// Template storage used by the session.
+	Templates *TemplateCache
+
+	// Optional queue for Data FlowSets received before their template. If
+	// nil such Data FlowSets are only reported in SessionPacket.Unknown.
+	Pending *PendingQueue
+
+	// Optional tracker of packet sequence numbers.
+	Sequences *SequenceTracker
+
+	// Optional normalizer learning samplers from Options Data Records.
+	Sampling *SamplingNormalizer
+
+	// Optional tables of interfaces, samplers and applications learned
+	// from Options Data Records.
+	Options *OptionsTables
+
+	lastExpire int64
+}
+
+// Expired templates and pending Data FlowSets are checked at most once per
+// expireInterval, so each packet does not have to scan whole cache.
+const expireInterval = time.Second
+
+// NewSession creates a session with an empty template cache.
+func NewSession() *Session {
+	return &Session{
+		Templates: NewTemplateCache(),
+	}
+}
+
+// Decode decodes raw packet bytes received from exporter addr. Any protocol
+// version supported by DecodeAny is accepted. Templates found in the packet
+// are learned before Data FlowSets are decoded, so Data FlowSets can refer to
+// templates sent in the same packet.
+func (s *Session) Decode(addr string, data []byte) (*SessionPacket, error) {
+	p, err := DecodeAny(data)
+	if err != nil {
+		return nil, err
+	}
+	return s.DecodePacket(addr, p), nil
+}
+
+// DecodePacket is the same as Decode but works with already decoded packet.
+// Data FlowSets replayed from the PendingQueue are listed before Data FlowSets
+// of the packet itself.
+func (s *Session) DecodePacket(addr string, p *Packet) *SessionPacket {
+	sp := &SessionPacket{Packet: p}
+
+	s.expire()
+	s.Templates.Learn(addr, p)
+
+	if s.Pending != nil {
+		for _, t := range p.TemplateRecords() {
+			s.replay(sp, TemplateKey{addr, p.SourceId, t.TemplateId})
+		}
+		for _, t := range p.OptionsTemplateRecords() {
+			s.replay(sp, TemplateKey{addr, p.SourceId, t.TemplateId})
+		}
+	}
+
+	replayedFlows, replayedOptions, replayedErrors := len(sp.Flows), len(sp.Options), len(sp.Errors)
+	for i, fs := range p.FlowSets {
+		set, ok := fs.(*DataFlowSet)
+		if !ok {
+			continue
+		}
+		key := TemplateKey{addr, p.SourceId, set.Id}
+		if s.decodeFlowSet(sp, key, p, set, i) {
+			continue
+		}
+		if s.Pending != nil {
+			s.Pending.Push(key, p, set)
+			// Template could have been learned by another goroutine
+			// after the lookup above, and its queue replayed before
+			// the push. Replay the queue now in that case.
+			if s.Templates.Template(key) != nil || s.Templates.OptionsTemplate(key) != nil {
+				s.replay(sp, key)
+				continue
+			}
+		}
+		sp.Unknown = append(sp.Unknown, set)
+	}
+
+	if s.Options != nil {
+		s.Options.Learn(addr, sp)
+	}
+	if s.Sampling != nil && s.Sampling.tables() != s.Options {
+		s.Sampling.Learn(addr, sp)
+	}
+
+	if s.Sequences != nil {
+		records := int(p.Count)
+		if p.Version == 10 {
+			// IPFIX header has no record count. Records of Data Sets
+			// with unknown templates or decode errors can not be
+			// counted, so the count is reported as unknown.
+			records = 0
+			for _, flows := range sp.Flows[replayedFlows:] {
+				records += len(flows.Records)
+			}
+			for _, options := range sp.Options[replayedOptions:] {
+				records += len(options.Records)
+			}
+			if len(sp.Unknown) > 0 || len(sp.Errors) > replayedErrors {
+				records = -1
+			}
+		}
+		sp.Sequence = s.Sequences.Track(addr, p, records)
+	}
+
+	return sp
+}
+
+func (s *Session) expire() {
+	now := s.Templates.now().UnixNano()
+	last := atomic.LoadInt64(&s.lastExpire)
+	if now-last < int64(expireInterval) || !atomic.CompareAndSwapInt64(&s.lastExpire, last, now) {
+		return
+	}
+
+	s.Templates.Expire()
+	if s.Pending != nil {
+		s.Pending.Expire()
+	}
+}
+
+func (s *Session) replay(sp *SessionPacket, key TemplateKey) {
+	for _, pending := range s.Pending.Take(key) {
+		s.decodeFlowSet(sp, key, pending.Packet, &pending.Set, -1)
+	}
+}
+
+// decodeFlowSet decodes Data FlowSet set with index in packet p. Index is
+// only used for error reporting, -1 means Data FlowSet is not a part of p.
+func (s *Session) decodeFlowSet(sp *SessionPacket, key TemplateKey, p *Packet, set *DataFlowSet, index int) bool {
+	if t := s.Templates.Template(key); t != nil {
+		records, err := t.DecodeRecords(set)
+		if err != nil {
+			sp.Errors = append(sp.Errors, flowSetError(err, index))
+		}
+		sp.Flows = append(sp.Flows, FlowRecords{p, t, records})
+		return true
+	}
+	if t := s.Templates.OptionsTemplate(key); t != nil {
+		records, err := t.DecodeRecords(set)
+		if err != nil {
+			sp.Errors = append(sp.Errors, flowSetError(err, index))
+		}
+		sp.Options = append(sp.Options, OptionsRecords{p, t, records})
+		return true
+	}
+	return false
+}
+
+// flowSetError sets FlowSet index of decode error err.
+func flowSetError(err error, index int) error {
+	var decodeErr *DecodeError
+	if errors.As(err, &decodeErr) {
+		decodeErr.FlowSet = index
+	}
+	return err
+}
@@ -0,0 +1,220 @@
+package nf9packet
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testTemplatePacket = []byte{
+		0x00, 0x09, // Version
+		0x00, 0x01, // Records count
+		0x00, 0x00, 0x01, 0x00, // System uptime
+		0x00, 0x00, 0x02, 0x00, // Timestamp
+		0x00, 0x00, 0x00, 0x01, // Sequence number
+		0x00, 0x00, 0x00, 0x07, // Source ID
+		0x00, 0x00, 0x00, 0x10, // Template FlowSet, length 16
+		0x01, 0x00, 0x00, 0x02, // Template 256, 2 fields
+		0x00, 0x08, 0x00, 0x04, // IPV4_SRC_ADDR, 4 bytes
+		0x00, 0x07, 0x00, 0x02, // L4_SRC_PORT, 2 bytes
+	}
+
+	testDataPacket = []byte{
+		0x00, 0x09, // Version
+		0x00, 0x02, // Records count
+		0x00, 0x00, 0x01, 0x00, // System uptime
+		0x00, 0x00, 0x02, 0x00, // Timestamp
+		0x00, 0x00, 0x00, 0x02, // Sequence number
+		0x00, 0x00, 0x00, 0x07, // Source ID
+		0x01, 0x00, 0x00, 0x10, // Data FlowSet 256, length 16
+		0x0a, 0x00, 0x00, 0x01, 0x00, 0x50, // 10.0.0.1:80
+		0x0a, 0x00, 0x00, 0x02, 0x01, 0xbb, // 10.0.0.2:443
+	}
+)
+
+func TestSessionDecode(t *testing.T) {
+	s := NewSession()
+
+	p, err := s.Decode("exporter", testDataPacket)
+	require.NoError(t, err)
+	assert.Empty(t, p.Flows)
+	assert.Len(t, p.Unknown, 1)
+
+	p, err = s.Decode("exporter", testTemplatePacket)
+	require.NoError(t, err)
+	assert.Empty(t, p.Flows)
+	assert.Empty(t, p.Unknown)
+
+	p, err = s.Decode("exporter", testDataPacket)
+	require.NoError(t, err)
+	require.Len(t, p.Flows, 1)
+	assert.Empty(t, p.Unknown)
+	assert.Equal(t, uint16(256), p.Flows[0].Template.TemplateId)
+	require.Len(t, p.Flows[0].Records, 2)
+	assert.Equal(t, []byte{0x01, 0xbb}, p.Flows[0].Records[1].Values[1])
+
+	p, err = s.Decode("other-exporter", testDataPacket)
+	require.NoError(t, err)
+	assert.Empty(t, p.Flows)
+	assert.Len(t, p.Unknown, 1)
+}
+
+func TestSessionDecodeErrors(t *testing.T) {
+	s := NewSession()
+	_, err := s.Decode("exporter", testTemplatePacket)
+	require.NoError(t, err)
+
+	data := append([]byte(nil), testDataPacket[:len(testDataPacket)-1]...)
+	data[23] = 0x0f // Second record is truncated
+	p, err := s.Decode("exporter", data)
+	require.NoError(t, err)
+	require.Len(t, p.Flows, 1)
+	assert.Len(t, p.Flows[0].Records, 1)
+
+	require.Len(t, p.Errors, 1)
+	var decodeErr *DecodeError
+	require.ErrorAs(t, p.Errors[0], &decodeErr)
+	assert.Equal(t, ErrTruncatedRecord, decodeErr.Err)
+	assert.Equal(t, 0, decodeErr.FlowSet)
+	assert.Equal(t, 6, decodeErr.Offset)
+	assert.Equal(t, uint16(256), decodeErr.FlowSetId)
+}
+
+func TestTemplateCacheKinds(t *testing.T) {
+	c := NewTemplateCache()
+	key := TemplateKey{"exporter", 1, 256}
+
+	c.AddTemplate(key, &TemplateRecord{256, 1, []Field{{Type: 8, Length: 4}}})
+	assert.NotNil(t, c.Template(key))
+	assert.Nil(t, c.OptionsTemplate(key))
+
+	c.AddOptionsTemplate(key, &OptionsTemplateRecord{256, 4, 0, []Field{{Type: 1, Length: 4}}, nil})
+	assert.Nil(t, c.Template(key))
+	assert.NotNil(t, c.OptionsTemplate(key))
+}
+
+func TestSessionConcurrent(t *testing.T) {
+	s := NewSession()
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_, err := s.Decode("exporter", testTemplatePacket)
+				assert.NoError(t, err)
+				_, err = s.Decode("exporter", testDataPacket)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestSessionPendingLearnedConcurrently(t *testing.T) {
+	s := NewSession()
+	s.Pending = NewPendingQueue(0, 0, 0)
+	tpl, err := Decode(testTemplatePacket)
+	require.NoError(t, err)
+
+	// Another goroutine learns the template after the lookup of the Data
+	// FlowSet template, but before the Data FlowSet is queued.
+	learned := false
+	s.Pending.now = func() time.Time {
+		if !learned {
+			learned = true
+			s.Templates.Learn("exporter", tpl)
+		}
+		return time.Now()
+	}
+
+	p, err := s.Decode("exporter", testDataPacket)
+	require.NoError(t, err)
+	assert.True(t, learned)
+	assert.Empty(t, p.Unknown)
+	require.Len(t, p.Flows, 1)
+	assert.Len(t, p.Flows[0].Records, 2)
+	assert.Zero(t, s.Pending.Len())
+}
+
+func TestSessionPendingReplay(t *testing.T) {
+	s := NewSession()
+	s.Pending = NewPendingQueue(4, 0, time.Minute)
+
+	p, err := s.Decode("exporter", testDataPacket)
+	require.NoError(t, err)
+	assert.Len(t, p.Unknown, 1)
+	assert.Equal(t, 1, s.Pending.Len())
+
+	p, err = s.Decode("exporter", testTemplatePacket)
+	require.NoError(t, err)
+	require.Len(t, p.Flows, 1)
+	assert.Equal(t, uint32(2), p.Flows[0].Packet.SequenceNumber)
+	assert.Len(t, p.Flows[0].Records, 2)
+	assert.Equal(t, 0, s.Pending.Len())
+}
+
+func TestPendingQueueLimits(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var expired []uint32
+
+	q := NewPendingQueue(2, 10, time.Minute)
+	q.now = func() time.Time { return now }
+	q.OnExpire = func(key TemplateKey, set *PendingFlowSet) {
+		expired = append(expired, set.Packet.SequenceNumber)
+	}
+
+	key := TemplateKey{"exporter", 1, 256}
+	set := &DataFlowSet{FlowSetHeader{256, 8}, []byte{1, 2, 3, 4}}
+
+	q.Push(key, &Packet{SequenceNumber: 1}, set)
+	q.Push(key, &Packet{SequenceNumber: 2}, set)
+	q.Push(key, &Packet{SequenceNumber: 3}, set)
+	assert.Equal(t, []uint32{1}, expired)
+	assert.Equal(t, 2, q.Len())
+
+	q.Push(TemplateKey{"exporter", 1, 257}, &Packet{SequenceNumber: 4}, &DataFlowSet{Data: make([]byte, 11)})
+	assert.Equal(t, []uint32{1, 4}, expired)
+
+	now = now.Add(2 * time.Minute)
+	q.Expire()
+	assert.Equal(t, []uint32{1, 4, 2, 3}, expired)
+	assert.Equal(t, 0, q.Len())
+	assert.Nil(t, q.Take(key))
+}
+
+func TestTemplateCacheEvents(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var events []TemplateEventType
+
+	c := NewTemplateCache()
+	c.Timeout = time.Minute
+	c.now = func() time.Time { return now }
+	c.OnEvent = func(ev TemplateEvent) {
+		events = append(events, ev.Type)
+	}
+
+	key := TemplateKey{"exporter", 1, 256}
+	tpl := &TemplateRecord{256, 1, []Field{{Type: 8, Length: 4}}}
+
+	c.AddTemplate(key, tpl)
+	c.AddTemplate(key, tpl)
+	c.AddTemplate(key, &TemplateRecord{256, 1, []Field{{Type: 12, Length: 4}}})
+	assert.Equal(t, []TemplateEventType{TemplateAdded, TemplateRefreshed, TemplateChanged}, events)
+	assert.Equal(t, uint16(12), c.Template(key).Fields[0].Type)
+
+	now = now.Add(2 * time.Minute)
+	assert.Nil(t, c.Template(key))
+	c.Expire()
+	assert.Equal(t, TemplateExpired, events[3])
+
+	c.AddTemplate(key, tpl)
+	c.AddTemplate(key, &TemplateRecord{TemplateId: 256})
+	assert.Equal(t, []TemplateEventType{TemplateAdded, TemplateWithdrawn}, events[4:])
+	assert.Nil(t, c.Template(key))
+}
@@ -1,7 +1,7 @@
 package nf9packet
 
 import (
-	"bytes"
+	"encoding/binary"
 )
 
 // TemplateRecord is a single template that describes structure of a Flow Record
@@ -62,55 +62,86 @@ type OptionsDataRecord struct {
 	OptionValues [][]byte
 }
 
-func parseFieldValues(buf *bytes.Buffer, fields []Field) (values [][]byte) {
-	values = make([][]byte, len(fields))
-	for i, f := range fields {
-		if buf.Len() < int(f.Length) {
-			return nil
+// fieldValue returns value of field f at the start of data and the number of
+// bytes it takes. For variable length fields length is read from data: a
+// single byte, or if it is 255, the following two bytes. Negative size is
+// returned if data is too short.
+func fieldValue(data []byte, f *Field) (value []byte, size int) {
+	length, prefix := int(f.Length), 0
+	if f.Length == VariableLength {
+		if len(data) < 1 {
+			return nil, -1
+		}
+		length, prefix = int(data[0]), 1
+		if length == 255 {
+			if len(data) < 3 {
+				return nil, -1
+			}
+			length, prefix = int(binary.BigEndian.Uint16(data[1:])), 3
 		}
-		values[i] = buf.Next(int(f.Length))
 	}
-	return
+	if len(data) < prefix+length {
+		return nil, -1
+	}
+	return data[prefix : prefix+length], prefix + length
 }
 
-// DecodeFlowSet uses current TemplateRecord to decode data in Data FlowSet to
-// a list of Flow Data Records.
-func (dtpl *TemplateRecord) DecodeFlowSet(set *DataFlowSet) (list []FlowDataRecord) {
-	var record FlowDataRecord
-	buf := bytes.NewBuffer(set.Data)
-
-	if set.Id != dtpl.TemplateId {
-		return
+// DecodeRecords uses current TemplateRecord to decode data in Data FlowSet to
+// a list of Flow Data Records. *DecodeError wrapping ErrTemplateMismatch is
+// returned if the Data FlowSet does not belong to this template. If the last
+// record is incomplete, records before it are returned together with a
+// *DecodeError wrapping ErrTruncatedRecord. Up to 3 bytes left after the last
+// record are treated as padding.
+func (dtpl *TemplateRecord) DecodeRecords(set *DataFlowSet) ([]FlowDataRecord, error) {
+	var list []FlowDataRecord
+
+	it := dtpl.Records(set)
+	for it.Next() {
+		list = append(list, FlowDataRecord{it.copyValues()})
 	}
+	return list, it.Err()
+}
 
-	// Assume total record length must be >= 4, otherwise it is impossible
-	// to distinguish between padding and new record. Padding MUST be
-	// supported.
-	for i := 0; buf.Len() >= 4; i++ {
-		record.Values = parseFieldValues(buf, dtpl.Fields)
-		list = append(list, record)
+// DecodeRecords uses current OptionsTemplateRecord to decode data in Data
+// FlowSet to a list of Options Data Records. Errors are reported the same way
+// as by TemplateRecord.DecodeRecords.
+func (otpl *OptionsTemplateRecord) DecodeRecords(set *DataFlowSet) ([]OptionsDataRecord, error) {
+	var list []OptionsDataRecord
+
+	it := otpl.Records(set)
+	for it.Next() {
+		values := it.copyValues()
+		list = append(list, OptionsDataRecord{values[:it.scopes], values[it.scopes:]})
 	}
+	return list, it.Err()
+}
 
+// DecodeFlowSet uses current TemplateRecord to decode data in Data FlowSet to
+// a list of Flow Data Records. It returns nil if the Data FlowSet does not
+// belong to this template and drops an incomplete last record, use
+// DecodeRecords to tell these cases apart.
+func (dtpl *TemplateRecord) DecodeFlowSet(set *DataFlowSet) (list []FlowDataRecord) {
+	list, _ = dtpl.DecodeRecords(set)
 	return
 }
 
 // DecodeFlowSet uses current OptionsTemplateRecord to decode data in Data
-// FlowSet to a list of Options Data Records.
+// FlowSet to a list of Options Data Records. It returns nil if the Data
+// FlowSet does not belong to this template and drops an incomplete last
+// record, use DecodeRecords to tell these cases apart.
 func (otpl *OptionsTemplateRecord) DecodeFlowSet(set *DataFlowSet) (list []OptionsDataRecord) {
-	var record OptionsDataRecord
-	buf := bytes.NewBuffer(set.Data)
-
-	if set.Id != otpl.TemplateId {
-		return
-	}
+	list, _ = otpl.DecodeRecords(set)
+	return
+}
 
-	// Assume total record length must be >= 4, otherwise it is impossible
-	// to distinguish between padding and new record. Padding MUST be
-	// supported.
-	for i := 0; buf.Len() >= 4; i++ {
-		record.ScopeValues = parseFieldValues(buf, otpl.Scopes)
-		record.OptionValues = parseFieldValues(buf, otpl.Options)
-		list = append(list, record)
+// findValue returns value of the first field of type fieldType or nil if
+// there is no such field. Enterprise-specific fields are skipped, their types
+// are not NetFlow v9 field types.
+func findValue(fields []Field, values [][]byte, fieldType uint16) []byte {
+	for i := range fields {
+		if fields[i].Type == fieldType && fields[i].EnterpriseNumber == 0 && i < len(values) {
+			return values[i]
+		}
 	}
-	return
+	return nil
 }
@@ -0,0 +1,162 @@
+package nf9packet
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testDecodeTemplate = TemplateRecord{
+	TemplateId: 256,
+	FieldCount: 2,
+	Fields: []Field{
+		{Type: 8, Length: 4}, // IPV4_SRC_ADDR
+		{Type: 7, Length: 2}, // L4_SRC_PORT
+	},
+}
+
+func TestDecodeRecords(t *testing.T) {
+	set := &DataFlowSet{
+		FlowSetHeader: FlowSetHeader{256, 20},
+		Data: []byte{
+			0x0a, 0x00, 0x00, 0x01, 0x00, 0x50,
+			0x0a, 0x00, 0x00, 0x02, 0x01, 0xbb,
+			0x00, 0x00, // Padding
+		},
+	}
+
+	records, err := testDecodeTemplate.DecodeRecords(set)
+	require.NoError(t, err)
+	assert.Equal(t, []FlowDataRecord{
+		{[][]byte{{0x0a, 0x00, 0x00, 0x01}, {0x00, 0x50}}},
+		{[][]byte{{0x0a, 0x00, 0x00, 0x02}, {0x01, 0xbb}}},
+	}, records)
+
+	set.Data = set.Data[:0]
+	records, err = testDecodeTemplate.DecodeRecords(set)
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func TestDecodeRecordsErrors(t *testing.T) {
+	set := &DataFlowSet{
+		FlowSetHeader: FlowSetHeader{257, 16},
+		Data: []byte{
+			0x0a, 0x00, 0x00, 0x01, 0x00, 0x50,
+			0x0a, 0x00, 0x00, 0x02, 0x01, // Last byte is missing
+		},
+	}
+
+	records, err := testDecodeTemplate.DecodeRecords(set)
+	assert.True(t, errors.Is(err, ErrTemplateMismatch))
+	assert.Nil(t, records)
+	assert.Nil(t, testDecodeTemplate.DecodeFlowSet(set))
+
+	var decodeErr *DecodeError
+	require.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, uint16(257), decodeErr.FlowSetId)
+	assert.Equal(t, uint16(256), decodeErr.TemplateId)
+
+	set.Id = 256
+	records, err = testDecodeTemplate.DecodeRecords(set)
+	assert.True(t, errors.Is(err, ErrTruncatedRecord))
+	assert.Len(t, records, 1)
+	assert.Len(t, testDecodeTemplate.DecodeFlowSet(set), 1)
+
+	require.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, 6, decodeErr.Offset)
+	assert.Equal(t, 5, decodeErr.Bytes)
+	assert.Equal(t, uint16(256), decodeErr.FlowSetId)
+	assert.Equal(t, -1, decodeErr.FlowSet)
+
+	otpl := OptionsTemplateRecord{
+		TemplateId: 256,
+		Scopes:     []Field{{Type: 1, Length: 4}},  // SYSTEM
+		Options:    []Field{{Type: 41, Length: 1}}, // TOTAL_PKTS_EXP
+	}
+	set.Data = []byte{0x00, 0x00, 0x00, 0x01}
+	options, err := otpl.DecodeRecords(set)
+	assert.True(t, errors.Is(err, ErrTruncatedRecord))
+	assert.Empty(t, options)
+}
+
+func TestRecordIterator(t *testing.T) {
+	set := &DataFlowSet{
+		FlowSetHeader: FlowSetHeader{256, 16},
+		Data: []byte{
+			0x0a, 0x00, 0x00, 0x01, 0x00, 0x50,
+			0x0a, 0x00, 0x00, 0x02, 0x01, // Last byte is missing
+		},
+	}
+
+	it := testDecodeTemplate.Records(set)
+	require.True(t, it.Next())
+	assert.Equal(t, FlowDataRecord{[][]byte{{0x0a, 0x00, 0x00, 0x01}, {0x00, 0x50}}}, it.Record())
+	assert.False(t, it.Next())
+	assert.True(t, errors.Is(it.Err(), ErrTruncatedRecord))
+
+	var count int
+	err := testDecodeTemplate.ForEachRecord(set, func(r FlowDataRecord) bool {
+		count++
+		return true
+	})
+	assert.True(t, errors.Is(err, ErrTruncatedRecord))
+	assert.Equal(t, 1, count)
+
+	otpl := OptionsTemplateRecord{
+		TemplateId: 256,
+		Scopes:     []Field{{Type: 1, Length: 4}},  // SYSTEM
+		Options:    []Field{{Type: 41, Length: 2}}, // TOTAL_PKTS_EXP
+	}
+	err = otpl.ForEachRecord(set, func(r OptionsDataRecord) bool {
+		assert.Equal(t, OptionsDataRecord{[][]byte{{0x0a, 0x00, 0x00, 0x01}}, [][]byte{{0x00, 0x50}}}, r)
+		return false
+	})
+	assert.NoError(t, err)
+}
+
+func benchmarkFlowSet(records int) *DataFlowSet {
+	var list []FlowDataRecord
+	for i := 0; i < records; i++ {
+		list = append(list, FlowDataRecord{[][]byte{{10, 0, 0, byte(i)}, {0, byte(i)}}})
+	}
+	set, _ := testDecodeTemplate.EncodeFlowSet(list)
+	return set
+}
+
+func BenchmarkDecodeFlowSet(b *testing.B) {
+	set := benchmarkFlowSet(100)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(set.Data)))
+	for i := 0; i < b.N; i++ {
+		for _, r := range testDecodeTemplate.DecodeFlowSet(set) {
+			_ = r.Values[0]
+		}
+	}
+}
+
+func BenchmarkRecords(b *testing.B) {
+	set := benchmarkFlowSet(100)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(set.Data)))
+	for i := 0; i < b.N; i++ {
+		it := testDecodeTemplate.Records(set)
+		for it.Next() {
+			_ = it.Record().Values[0]
+		}
+	}
+}
+
+func BenchmarkForEachRecord(b *testing.B) {
+	set := benchmarkFlowSet(100)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(set.Data)))
+	for i := 0; i < b.N; i++ {
+		testDecodeTemplate.ForEachRecord(set, func(r FlowDataRecord) bool {
+			_ = r.Values[0]
+			return true
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package nf9packet
+
+import (
+	"time"
+)
+
+// Field types holding flow timestamps.
+const (
+	fieldLastSwitched          = 21
+	fieldFirstSwitched         = 22
+	fieldFlowStartSeconds      = 150
+	fieldFlowEndSeconds        = 151
+	fieldFlowStartMilliseconds = 152
+	fieldFlowEndMilliseconds   = 153
+)
+
+// Time converts sysUptime based timestamp (as found in FIRST_SWITCHED and
+// LAST_SWITCHED fields) to absolute time using packet SysUpTime and UnixSecs.
+// The difference is calculated modulo 2^32, so timestamps taken before
+// exporter uptime wrapped around (every ~49.7 days) are converted correctly as
+// long as they are less than ~24.8 days older than the packet. UnixSecs has
+// one second precision, so the result may be up to a second off.
+func (p *Packet) Time(uptime uint32) time.Time {
+	age := time.Duration(int32(p.SysUpTime-uptime)) * time.Millisecond
+	return time.Unix(int64(p.UnixSecs), 0).Add(-age).UTC()
+}
+
+// FlowTimes returns absolute start and end time of Flow Data Record r decoded
+// from packet p using template tpl. Absolute FLOW_START_MILLISECONDS and
+// FLOW_END_MILLISECONDS are preferred, then FLOW_START_SECONDS and
+// FLOW_END_SECONDS, then FIRST_SWITCHED and LAST_SWITCHED converted with
+// Packet.Time. IPFIX messages carry no sysUptime, so the last pair is not used
+// for them. Enterprise-specific fields are ignored. If template has no usable
+// timestamps ok is false.
+func (r *FlowDataRecord) FlowTimes(p *Packet, tpl *TemplateRecord) (start, end time.Time, ok bool) {
+	var found [fieldFlowEndMilliseconds + 1][]byte
+	for i := range tpl.Fields {
+		t := tpl.Fields[i].Type
+		if i >= len(r.Values) || tpl.Fields[i].EnterpriseNumber != 0 || int(t) >= len(found) || found[t] != nil {
+			continue
+		}
+		found[t] = r.Values[i]
+	}
+
+	pairs := [][2]uint16{
+		{fieldFlowStartMilliseconds, fieldFlowEndMilliseconds},
+		{fieldFlowStartSeconds, fieldFlowEndSeconds},
+		{fieldFirstSwitched, fieldLastSwitched},
+	}
+	for _, pair := range pairs {
+		s, e := found[pair[0]], found[pair[1]]
+		if s == nil || e == nil {
+			continue
+		}
+		switch pair[0] {
+		case fieldFlowStartMilliseconds:
+			if len(s) != 8 || len(e) != 8 {
+				continue
+			}
+			start = time.UnixMilli(int64(fieldToUInteger(s))).UTC()
+			end = time.UnixMilli(int64(fieldToUInteger(e))).UTC()
+		case fieldFlowStartSeconds:
+			if len(s) != 4 || len(e) != 4 {
+				continue
+			}
+			start = time.Unix(int64(fieldToUInteger(s)), 0).UTC()
+			end = time.Unix(int64(fieldToUInteger(e)), 0).UTC()
+		case fieldFirstSwitched:
+			if p.Version == 10 || len(s) != 4 || len(e) != 4 {
+				continue
+			}
+			start = p.Time(uint32(fieldToUInteger(s)))
+			end = p.Time(uint32(fieldToUInteger(e)))
+		}
+		return start, end, true
+	}
+	return time.Time{}, time.Time{}, false
+}
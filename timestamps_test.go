@@ -0,0 +1,81 @@
+package nf9packet
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPacketTime(t *testing.T) {
+	p := &Packet{Version: 9, SysUpTime: 10000, UnixSecs: 1600000000}
+	now := time.Unix(1600000000, 0).UTC()
+
+	assert.Equal(t, now, p.Time(10000))
+	assert.Equal(t, now.Add(-2500*time.Millisecond), p.Time(7500))
+	assert.Equal(t, now.Add(time.Second), p.Time(11000))
+
+	// Flow started before sysUptime wrapped around.
+	p.SysUpTime = 1000
+	assert.Equal(t, now.Add(-3*time.Second), p.Time(0xffffffff-1999))
+}
+
+func TestFlowTimes(t *testing.T) {
+	u32 := func(v uint32) []byte {
+		return binary.BigEndian.AppendUint32(nil, v)
+	}
+	u64 := func(v uint64) []byte {
+		return binary.BigEndian.AppendUint64(nil, v)
+	}
+	p := &Packet{Version: 9, SysUpTime: 60000, UnixSecs: 1600000000}
+	now := time.Unix(1600000000, 0).UTC()
+
+	relative := &TemplateRecord{256, 2, []Field{{Type: 22, Length: 4}, {Type: 21, Length: 4}}}
+	rec := &FlowDataRecord{[][]byte{u32(30000), u32(50000)}}
+	start, end, ok := rec.FlowTimes(p, relative)
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(-30*time.Second), start)
+	assert.Equal(t, now.Add(-10*time.Second), end)
+
+	seconds := &TemplateRecord{257, 2, []Field{{Type: 150, Length: 4}, {Type: 151, Length: 4}}}
+	rec = &FlowDataRecord{[][]byte{u32(1599999990), u32(1599999995)}}
+	start, end, ok = rec.FlowTimes(p, seconds)
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(-10*time.Second), start)
+	assert.Equal(t, now.Add(-5*time.Second), end)
+
+	// Absolute milliseconds are preferred over sysUptime based timestamps.
+	mixed := &TemplateRecord{258, 4, []Field{{Type: 22, Length: 4}, {Type: 21, Length: 4}, {Type: 152, Length: 8}, {Type: 153, Length: 8}}}
+	rec = &FlowDataRecord{[][]byte{u32(30000), u32(50000), u64(1599999990500), u64(1599999999250)}}
+	start, end, ok = rec.FlowTimes(p, mixed)
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(-9500*time.Millisecond), start)
+	assert.Equal(t, now.Add(-750*time.Millisecond), end)
+
+	// IPFIX messages have no sysUptime.
+	ipfix := &Packet{Version: 10, UnixSecs: 1600000000}
+	rec = &FlowDataRecord{[][]byte{u32(30000), u32(50000)}}
+	_, _, ok = rec.FlowTimes(ipfix, relative)
+	assert.False(t, ok)
+
+	_, _, ok = rec.FlowTimes(p, &testDecodeTemplate)
+	assert.False(t, ok)
+
+	// Enterprise-specific fields with the same type are not timestamps.
+	enterprise := &TemplateRecord{259, 4, []Field{
+		{Type: 152, Length: 8, EnterpriseNumber: 9},
+		{Type: 153, Length: 8, EnterpriseNumber: 9},
+		{Type: 150, Length: 4},
+		{Type: 151, Length: 4},
+	}}
+	rec = &FlowDataRecord{[][]byte{u64(1), u64(2), u32(1599999990), u32(1599999995)}}
+	start, end, ok = rec.FlowTimes(p, enterprise)
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(-10*time.Second), start)
+	assert.Equal(t, now.Add(-5*time.Second), end)
+
+	enterprise = &TemplateRecord{260, 2, []Field{{Type: 22, Length: 4, EnterpriseNumber: 9}, {Type: 21, Length: 4, EnterpriseNumber: 9}}}
+	_, _, ok = (&FlowDataRecord{[][]byte{u32(30000), u32(50000)}}).FlowTimes(p, enterprise)
+	assert.False(t, ok)
+}
@@ -0,0 +1,161 @@
+package nf9packet
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"reflect"
+	"time"
+)
+
+// ToMap converts Flow Data Record to a map keyed by field names as returned by
+// Field.Name(). Values are converted using Field.Value, values that can not be
+// converted are stored as raw []byte. If template contains the same field
+// type more than once only the first value is kept.
+func (r *FlowDataRecord) ToMap(tpl *TemplateRecord) map[string]interface{} {
+	m := make(map[string]interface{}, len(tpl.Fields))
+	for i := range tpl.Fields {
+		if i >= len(r.Values) {
+			break
+		}
+		name := tpl.Fields[i].Name()
+		if _, ok := m[name]; ok {
+			continue
+		}
+		v, err := tpl.Fields[i].Value(r.Values[i])
+		if err != nil {
+			v = append([]byte(nil), r.Values[i]...)
+		}
+		m[name] = v
+	}
+	return m
+}
+
+var (
+	typeNetipAddr    = reflect.TypeOf(netip.Addr{})
+	typeIP           = reflect.TypeOf(net.IP{})
+	typeHardwareAddr = reflect.TypeOf(net.HardwareAddr{})
+	typeDuration     = reflect.TypeOf(time.Duration(0))
+	typeBytes        = reflect.TypeOf([]byte{})
+)
+
+// Unmarshal stores values of Flow Data Record rec, decoded with template tpl,
+// in the struct pointed to by v. Struct fields are matched with template fields
+// using "nf9" tag holding the field name as returned by Field.Name(), for
+// example:
+//
+//	type Flow struct {
+//		Src   netip.Addr `nf9:"IPV4_SRC_ADDR"`
+//		Port  uint16     `nf9:"L4_SRC_PORT"`
+//		Bytes uint64     `nf9:"IN_BYTES"`
+//	}
+//
+// Integer values of any length up to 8 bytes are stored in any integer struct
+// field as long as the value fits. Addresses can be stored in netip.Addr,
+// net.IP or string fields, MAC addresses in net.HardwareAddr or string fields.
+// Any value can be stored in a []byte field as a raw copy. Struct fields
+// without a matching template field are left unchanged.
+func Unmarshal(tpl *TemplateRecord, rec *FlowDataRecord, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("Unmarshal target must be a non-nil pointer to a struct.")
+	}
+	rv = rv.Elem()
+
+	index := make(map[string]int, len(tpl.Fields))
+	for i := len(tpl.Fields) - 1; i >= 0; i-- {
+		index[tpl.Fields[i].Name()] = i
+	}
+
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		name := rt.Field(i).Tag.Get("nf9")
+		if name == "" || name == "-" {
+			continue
+		}
+		j, ok := index[name]
+		if !ok || j >= len(rec.Values) {
+			continue
+		}
+		if err := setField(rv.Field(i), &tpl.Fields[j], rec.Values[j]); err != nil {
+			return fmt.Errorf("Can not store %s in field %s: %w", name, rt.Field(i).Name, err)
+		}
+	}
+
+	return nil
+}
+
+func setField(dst reflect.Value, f *Field, data []byte) error {
+	if !dst.CanSet() {
+		return errors.New("field is not exported")
+	}
+
+	if dst.Type() == typeBytes {
+		dst.SetBytes(append([]byte(nil), data...))
+		return nil
+	}
+
+	value, err := f.Value(data)
+	if err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case uint64:
+		return setUInteger(dst, v)
+	case TCPFlags:
+		return setUInteger(dst, uint64(v))
+	case time.Duration:
+		if dst.Type() == typeDuration {
+			dst.SetInt(int64(v))
+			return nil
+		}
+		return setUInteger(dst, uint64(v/time.Millisecond))
+	case netip.Addr:
+		switch dst.Type() {
+		case typeNetipAddr:
+			dst.Set(reflect.ValueOf(v))
+			return nil
+		case typeIP:
+			dst.Set(reflect.ValueOf(net.IP(v.AsSlice())))
+			return nil
+		}
+	case net.HardwareAddr:
+		if dst.Type() == typeHardwareAddr {
+			dst.Set(reflect.ValueOf(v))
+			return nil
+		}
+	}
+
+	if s, ok := value.(fmt.Stringer); ok && dst.Kind() == reflect.String {
+		dst.SetString(s.String())
+		return nil
+	}
+	if reflect.TypeOf(value).AssignableTo(dst.Type()) {
+		dst.Set(reflect.ValueOf(value))
+		return nil
+	}
+
+	return fmt.Errorf("incompatible type %s", dst.Type())
+}
+
+func setUInteger(dst reflect.Value, v uint64) error {
+	switch dst.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if dst.OverflowUint(v) {
+			return fmt.Errorf("value %d overflows %s", v, dst.Type())
+		}
+		dst.SetUint(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v > 1<<63-1 || dst.OverflowInt(int64(v)) {
+			return fmt.Errorf("value %d overflows %s", v, dst.Type())
+		}
+		dst.SetInt(int64(v))
+	case reflect.String:
+		dst.SetString(fmt.Sprint(v))
+	default:
+		return fmt.Errorf("incompatible type %s", dst.Type())
+	}
+	return nil
+}
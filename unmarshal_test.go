@@ -0,0 +1,78 @@
+package nf9packet
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testRecordTemplate = &TemplateRecord{256, 5, []Field{
+	{Type: 8, Length: 4},  // IPV4_SRC_ADDR
+	{Type: 7, Length: 2},  // L4_SRC_PORT
+	{Type: 1, Length: 8},  // IN_BYTES
+	{Type: 56, Length: 6}, // IN_SRC_MAC
+	{Type: 22, Length: 4}, // FIRST_SWITCHED
+}}
+
+var testRecord = &FlowDataRecord{[][]byte{
+	{192, 168, 0, 1},
+	{0x00, 0x50},
+	{0, 0, 0, 0, 0, 0, 0x10, 0x00},
+	{0, 1, 2, 3, 4, 5},
+	{0, 0, 0x03, 0xe8},
+}}
+
+func TestFlowDataRecordToMap(t *testing.T) {
+	assert.Equal(t, map[string]interface{}{
+		"IPV4_SRC_ADDR":  netip.MustParseAddr("192.168.0.1"),
+		"L4_SRC_PORT":    uint64(80),
+		"IN_BYTES":       uint64(4096),
+		"IN_SRC_MAC":     net.HardwareAddr{0, 1, 2, 3, 4, 5},
+		"FIRST_SWITCHED": time.Second,
+	}, testRecord.ToMap(testRecordTemplate))
+}
+
+func TestUnmarshal(t *testing.T) {
+	var flow struct {
+		Src     netip.Addr       `nf9:"IPV4_SRC_ADDR"`
+		SrcIP   net.IP           `nf9:"IPV4_SRC_ADDR"`
+		SrcStr  string           `nf9:"IPV4_SRC_ADDR"`
+		Port    uint16           `nf9:"L4_SRC_PORT"`
+		Bytes   int              `nf9:"IN_BYTES"`
+		MAC     net.HardwareAddr `nf9:"IN_SRC_MAC"`
+		First   time.Duration    `nf9:"FIRST_SWITCHED"`
+		FirstMs uint32           `nf9:"FIRST_SWITCHED"`
+		Raw     []byte           `nf9:"L4_SRC_PORT"`
+		Missing uint64           `nf9:"OUT_BYTES"`
+	}
+
+	require.NoError(t, Unmarshal(testRecordTemplate, testRecord, &flow))
+	assert.Equal(t, netip.MustParseAddr("192.168.0.1"), flow.Src)
+	assert.Equal(t, "192.168.0.1", flow.SrcIP.String())
+	assert.Equal(t, "192.168.0.1", flow.SrcStr)
+	assert.Equal(t, uint16(80), flow.Port)
+	assert.Equal(t, 4096, flow.Bytes)
+	assert.Equal(t, net.HardwareAddr{0, 1, 2, 3, 4, 5}, flow.MAC)
+	assert.Equal(t, time.Second, flow.First)
+	assert.Equal(t, uint32(1000), flow.FirstMs)
+	assert.Equal(t, []byte{0x00, 0x50}, flow.Raw)
+	assert.Zero(t, flow.Missing)
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	var overflow struct {
+		Bytes uint8 `nf9:"IN_BYTES"`
+	}
+	assert.Error(t, Unmarshal(testRecordTemplate, testRecord, &overflow))
+
+	var incompatible struct {
+		Src uint32 `nf9:"IPV4_SRC_ADDR"`
+	}
+	assert.Error(t, Unmarshal(testRecordTemplate, testRecord, &incompatible))
+
+	assert.Error(t, Unmarshal(testRecordTemplate, testRecord, overflow))
+}
@@ -0,0 +1,271 @@
+package nf9packet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// Value is a typed representation of a single field value returned by
+// Field.Value. Actual value type depends on the field type:
+//
+//	uint64           counters, identifiers, ports, enumerations
+//	netip.Addr       IPv4 and IPv6 addresses
+//	net.HardwareAddr MAC addresses
+//	time.Duration    sysUptime based timestamps (FIRST_SWITCHED, ...)
+//	time.Time        absolute timestamps (FLOW_START_MILLISECONDS, ...)
+//	string           names and descriptions
+//	TCPFlags         cumulative TCP flags
+//	ICMPTypeCode     ICMP type and code
+//	MPLSLabel        MPLS label stack entries
+//	ApplicationTag   application classification (APPLICATION_TAG)
+//	[]byte           vendor proprietary and unknown field types
+type Value interface{}
+
+// TCPFlags is a bit set of TCP flags as seen in TCP_FLAGS field.
+type TCPFlags uint8
+
+// TCP flag bits.
+const (
+	TCPFlagFIN TCPFlags = 1 << iota
+	TCPFlagSYN
+	TCPFlagRST
+	TCPFlagPSH
+	TCPFlagACK
+	TCPFlagURG
+	TCPFlagECE
+	TCPFlagCWR
+)
+
+// Has reports whether all flags in mask are set.
+func (f TCPFlags) Has(mask TCPFlags) bool {
+	return f&mask == mask
+}
+
+// String returns flags in "CEUAPRSF" format, using space for unset flags.
+func (f TCPFlags) String() string {
+	const names = "FSRPAUEC"
+	var b strings.Builder
+	for i := len(names) - 1; i >= 0; i-- {
+		if f&(1<<uint(i)) != 0 {
+			b.WriteByte(names[i])
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	return b.String()
+}
+
+// ICMPTypeCode is ICMP message type and code.
+type ICMPTypeCode struct {
+	Type uint8
+	Code uint8
+}
+
+// String returns type and code in "type/code" format.
+func (i ICMPTypeCode) String() string {
+	return fmt.Sprintf("%d/%d", i.Type, i.Code)
+}
+
+// MPLSLabel is a single MPLS label stack entry.
+type MPLSLabel struct {
+	// 20 bit label value.
+	Label uint32
+
+	// 3 bit experimental (traffic class) field.
+	Exp uint8
+
+	// Bottom of stack bit.
+	BottomOfStack bool
+}
+
+// String returns label in "label/exp/bottom" format.
+func (l MPLSLabel) String() string {
+	bottom := 0
+	if l.BottomOfStack {
+		bottom = 1
+	}
+	return fmt.Sprintf("%d/%d/%d", l.Label, l.Exp, bottom)
+}
+
+// Classification Engine IDs of ApplicationTag as defined by RFC 6759.
+const (
+	EngineIANAL3    = 1
+	EnginePANAL3    = 2
+	EngineIANAL4    = 3
+	EnginePANAL4    = 4
+	EngineUser      = 6
+	EnginePANAL2    = 12
+	EnginePANAL7    = 13
+	EngineEthertype = 18
+	EngineLLC       = 19
+	EnginePANAL7PEN = 20
+)
+
+var engineNames = map[uint8]string{
+	EngineIANAL3:    "IANA-L3",
+	EnginePANAL3:    "PANA-L3",
+	EngineIANAL4:    "IANA-L4",
+	EnginePANAL4:    "PANA-L4",
+	EngineUser:      "USER",
+	EnginePANAL2:    "PANA-L2",
+	EnginePANAL7:    "PANA-L7",
+	EngineEthertype: "ETHERTYPE",
+	EngineLLC:       "LLC",
+	EnginePANAL7PEN: "PANA-L7-PEN",
+}
+
+// ApplicationTag is an application classification as seen in APPLICATION_TAG
+// field: Classification Engine ID followed by Selector ID, whose meaning
+// depends on the engine. For example IANA-L4 selectors are well known port
+// numbers, while NBAR uses PANA-L7 selectors assigned by Cisco. PANA-L7-PEN
+// selectors are assigned by the vendor identified by the Private Enterprise
+// Number sent in front of the Selector ID (RFC 6759).
+type ApplicationTag struct {
+	Engine uint8
+
+	// Private Enterprise Number for PANA-L7-PEN engine, zero otherwise.
+	PEN uint32
+
+	Selector uint64
+}
+
+// EngineName returns short name of the Classification Engine, for example
+// "IANA-L4", or engine ID as a string for unknown engines.
+func (t ApplicationTag) EngineName() string {
+	if name, ok := engineNames[t.Engine]; ok {
+		return name
+	}
+	return fmt.Sprintf("%d", t.Engine)
+}
+
+// String returns tag in "engine:selector" format, for example "IANA-L4:80",
+// or "engine:pen:selector" format for PANA-L7-PEN engine.
+func (t ApplicationTag) String() string {
+	if t.Engine == EnginePANAL7PEN {
+		return fmt.Sprintf("%s:%d:%d", t.EngineName(), t.PEN, t.Selector)
+	}
+	return fmt.Sprintf("%s:%d", t.EngineName(), t.Selector)
+}
+
+// ParseApplicationTag splits raw APPLICATION_TAG value into Classification
+// Engine ID, Private Enterprise Number (PANA-L7-PEN engine only) and Selector
+// ID. Selector IDs longer than 8 bytes are not supported.
+func ParseApplicationTag(data []byte) (ApplicationTag, error) {
+	if len(data) > 0 && data[0] == EnginePANAL7PEN {
+		if len(data) < 6 || len(data) > 13 {
+			return ApplicationTag{}, errorValueLength(len(data), "6-13")
+		}
+		return ApplicationTag{data[0], binary.BigEndian.Uint32(data[1:]), fieldToUInteger(data[5:])}, nil
+	}
+	if len(data) < 2 || len(data) > 9 {
+		return ApplicationTag{}, errorValueLength(len(data), "2-9")
+	}
+	return ApplicationTag{Engine: data[0], Selector: fieldToUInteger(data[1:])}, nil
+}
+
+// Value converts field value to a typed representation based on field type.
+// Error is returned if data length is not valid for the field type. For
+// unknown and enterprise-specific field types a copy of data is returned as
+// []byte.
+func (f *Field) Value(data []byte) (Value, error) {
+	if e, ok := f.dbEntry(); ok {
+		return e.Value(data)
+	}
+	return fieldToValueBytes(data)
+}
+
+func fieldToValueUInteger(data []byte) (Value, error) {
+	if len(data) < 1 || len(data) > 8 {
+		return nil, errorValueLength(len(data), "1-8")
+	}
+	return fieldToUInteger(data), nil
+}
+
+func fieldToValueBytes(data []byte) (Value, error) {
+	return append([]byte(nil), data...), nil
+}
+
+// asciiString converts string field value to a Go string.
+func asciiString(data []byte) string {
+	// Exporters pad fixed length strings with zero bytes.
+	return strings.TrimRight(string(data), "\x00")
+}
+
+func fieldToValueASCII(data []byte) (Value, error) {
+	return asciiString(data), nil
+}
+
+func fieldToValueIP(data []byte) (Value, error) {
+	if len(data) != 4 && len(data) != 16 {
+		return nil, errorValueLength(len(data), "4 or 16")
+	}
+	addr, _ := netip.AddrFromSlice(data)
+	return addr, nil
+}
+
+func fieldToValueMAC(data []byte) (Value, error) {
+	if len(data) != 6 {
+		return nil, errorValueLength(len(data), "6")
+	}
+	return net.HardwareAddr(append([]byte(nil), data...)), nil
+}
+
+func fieldToValueTCPFlags(data []byte) (Value, error) {
+	// IPFIX exporters use 2 bytes, upper byte holds flags that do not fit
+	// TCPFlags.
+	if len(data) != 1 && len(data) != 2 {
+		return nil, errorValueLength(len(data), "1 or 2")
+	}
+	return TCPFlags(data[len(data)-1]), nil
+}
+
+func fieldToValueICMPTypeCode(data []byte) (Value, error) {
+	if len(data) != 2 {
+		return nil, errorValueLength(len(data), "2")
+	}
+	return ICMPTypeCode{data[0], data[1]}, nil
+}
+
+func fieldToValueMsecDuration(data []byte) (Value, error) {
+	if len(data) < 1 || len(data) > 8 {
+		return nil, errorValueLength(len(data), "1-8")
+	}
+	return time.Duration(fieldToUInteger(data)) * time.Millisecond, nil
+}
+
+func fieldToValueDateTimeSeconds(data []byte) (Value, error) {
+	if len(data) != 4 {
+		return nil, errorValueLength(len(data), "4")
+	}
+	return time.Unix(int64(fieldToUInteger(data)), 0).UTC(), nil
+}
+
+func fieldToValueDateTimeMilliseconds(data []byte) (Value, error) {
+	if len(data) != 8 {
+		return nil, errorValueLength(len(data), "8")
+	}
+	return time.UnixMilli(int64(fieldToUInteger(data))).UTC(), nil
+}
+
+func fieldToValueApplicationTag(data []byte) (Value, error) {
+	t, err := ParseApplicationTag(data)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func fieldToValueMPLSLabel(data []byte) (Value, error) {
+	if len(data) != 3 {
+		return nil, errorValueLength(len(data), "3")
+	}
+	return MPLSLabel{
+		Label:         uint32(data[0])<<12 | uint32(data[1])<<4 | uint32(data[2])>>4,
+		Exp:           (data[2] >> 1) & 0x07,
+		BottomOfStack: data[2]&0x01 != 0,
+	}, nil
+}
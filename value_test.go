@@ -0,0 +1,89 @@
+package nf9packet
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldValue(t *testing.T) {
+	tests := []struct {
+		field    Field
+		data     []byte
+		expected Value
+	}{
+		{Field{Type: 1, Length: 2}, []byte{0x01, 0x00}, uint64(256)},
+		{Field{Type: 8, Length: 4}, []byte{10, 0, 0, 1}, netip.MustParseAddr("10.0.0.1")},
+		{Field{Type: 27, Length: 16}, net.ParseIP("2001:db8::1"), netip.MustParseAddr("2001:db8::1")},
+		{Field{Type: 56, Length: 6}, []byte{0, 1, 2, 3, 4, 5}, net.HardwareAddr{0, 1, 2, 3, 4, 5}},
+		{Field{Type: 22, Length: 4}, []byte{0, 0, 0x03, 0xe8}, time.Second},
+		{Field{Type: 150, Length: 4}, []byte{0x5f, 0x5e, 0x10, 0x00}, time.Unix(1600000000, 0).UTC()},
+		{Field{Type: 152, Length: 8}, []byte{0, 0, 0x01, 0x74, 0x87, 0x6e, 0x80, 0x7b}, time.UnixMilli(1600000000123).UTC()},
+		{Field{Type: 6, Length: 1}, []byte{0x12}, TCPFlagSYN | TCPFlagACK},
+		{Field{Type: 32, Length: 2}, []byte{3, 1}, ICMPTypeCode{3, 1}},
+		{Field{Type: 70, Length: 3}, []byte{0x00, 0x01, 0x0b}, MPLSLabel{16, 5, true}},
+		{Field{Type: 82, Length: 8}, []byte("Gi0/1\x00\x00\x00"), "Gi0/1"},
+		{Field{Type: 95, Length: 4}, []byte{13, 0, 0, 0x50}, ApplicationTag{Engine: EnginePANAL7, Selector: 80}},
+		{Field{Type: 65000, Length: 2}, []byte{0xab, 0xcd}, []byte{0xab, 0xcd}},
+	}
+
+	for _, test := range tests {
+		actual, err := test.field.Value(test.data)
+		require.NoError(t, err, test.field.Name())
+		assert.Equal(t, test.expected, actual, test.field.Name())
+	}
+}
+
+func TestFieldValueLength(t *testing.T) {
+	for _, f := range []Field{{Type: 1, Length: 0}, {Type: 1, Length: 9}, {Type: 8, Length: 3}, {Type: 56, Length: 5}, {Type: 6, Length: 0}, {Type: 32, Length: 1}, {Type: 70, Length: 2}, {Type: 151, Length: 8}, {Type: 153, Length: 4}, {Type: 95, Length: 1}} {
+		_, err := f.Value(make([]byte, f.Length))
+		var decodeErr *DecodeError
+		require.ErrorAs(t, err, &decodeErr, f.Name())
+		assert.ErrorIs(t, err, ErrValueLength, f.Name())
+		assert.Equal(t, int(f.Length), decodeErr.Bytes, f.Name())
+		assert.NotPanics(t, func() { f.DataToString(make([]byte, f.Length)) }, f.Name())
+	}
+}
+
+func TestFieldDb(t *testing.T) {
+	names := make(map[string]uint16)
+	for fieldType, e := range fieldDb {
+		f := Field{Type: fieldType, Length: uint16(e.Length)}
+		if prev, ok := names[e.Name]; ok {
+			t.Errorf("%s used by field types %d and %d", e.Name, prev, fieldType)
+		}
+		names[e.Name] = fieldType
+
+		if e.Length > 0 {
+			_, err := f.Value(make([]byte, e.Length))
+			assert.NoError(t, err, e.Name)
+		}
+	}
+
+	tests := []struct {
+		field    Field
+		data     []byte
+		expected string
+	}{
+		{Field{Type: 128, Length: 4}, []byte{0, 0, 0xfd, 0xe8}, "65000"},
+		{Field{Type: 136, Length: 1}, []byte{2}, "Active timeout"},
+		{Field{Type: 176, Length: 1}, []byte{8}, "8"},
+		{Field{Type: 225, Length: 4}, []byte{192, 0, 2, 1}, "192.0.2.1"},
+		{Field{Type: 230, Length: 1}, []byte{16}, "Port block allocation"},
+		{Field{Type: 233, Length: 1}, []byte{3}, "Flow denied"},
+		{Field{Type: 33000, Length: 12}, []byte{0, 0, 0, 1, 0, 0, 0, 2, 0, 0, 0, 3}, "0x000000010000000200000003"},
+		{Field{Type: 40000, Length: 20}, []byte("admin"), "admin"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, test.field.DataToString(test.data), test.field.Name())
+	}
+}
+
+func TestTCPFlagsString(t *testing.T) {
+	assert.Equal(t, "   A  S ", (TCPFlagSYN | TCPFlagACK).String())
+	assert.True(t, (TCPFlagSYN | TCPFlagACK).Has(TCPFlagACK))
+}